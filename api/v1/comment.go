@@ -1,6 +1,8 @@
 package api
 
 import (
+	"errors"
+
 	"clicli/domain/dto"
 	"clicli/domain/resp"
 	"clicli/domain/valid"
@@ -28,6 +30,12 @@ func Comment(ctx *gin.Context) {
 		return
 	}
 
+	if service.ContainsFilterWord(commentDTO.Content) {
+		resp.Response(ctx, resp.ContentFilterError, "", nil)
+		zap.L().Error("评论包含违禁词")
+		return
+	}
+
 	userId := ctx.GetUint("userId")
 
 	// 处理@的用户
@@ -71,6 +79,12 @@ func Reply(ctx *gin.Context) {
 		return
 	}
 
+	if service.ContainsFilterWord(commentDTO.Content) {
+		resp.Response(ctx, resp.ContentFilterError, "", nil)
+		zap.L().Error("评论包含违禁词")
+		return
+	}
+
 	userId := ctx.GetUint("userId")
 
 	// 将DTO转为model
@@ -206,3 +220,48 @@ func DeleteReply(ctx *gin.Context) {
 	// 返回给前端
 	resp.OK(ctx, "ok", nil)
 }
+
+// 搜索评论和回复
+func SearchComment(ctx *gin.Context) {
+	keywords := ctx.Query("keywords")
+	vid := convert.StringToUint(ctx.DefaultQuery("vid", "0"))
+	page := convert.StringToInt(ctx.DefaultQuery("page", "1"))
+	pageSize := convert.StringToInt(ctx.DefaultQuery("page_size", "15"))
+
+	if pageSize > 30 {
+		resp.Response(ctx, resp.TooManyRequestsError, "", nil)
+		zap.L().Error("请求数量过多")
+		return
+	}
+
+	if page < 1 || pageSize < 1 {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	if len(keywords) == 0 {
+		resp.Response(ctx, resp.RequestParamError, valid.CONTENT_ERROR, nil)
+		zap.L().Error(valid.CONTENT_ERROR)
+		return
+	}
+
+	total, hits, err := service.SearchComment(keywords, vid, page, pageSize)
+	if errors.Is(err, service.ErrSearchNotReady) {
+		resp.Response(ctx, resp.SearchNotReadyError, "", nil)
+		return
+	}
+	if err != nil {
+		resp.Response(ctx, resp.SelectError, "", nil)
+		zap.L().Error("搜索评论失败" + err.Error())
+		return
+	}
+
+	comments := make([]vo.SearchCommentVO, len(hits))
+	for i, hit := range hits {
+		comments[i] = vo.ToSearchCommentVO(hit, service.GetUserInfo(uint(hit.Attrs["uid"])))
+	}
+
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"total": total, "comments": comments})
+}
@@ -5,8 +5,10 @@ import (
 
 	"clicli/domain/dto"
 	"clicli/domain/resp"
+	"clicli/domain/valid"
 	"clicli/domain/vo"
 	"clicli/initialize"
+	"clicli/service"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
@@ -50,9 +52,8 @@ func SetEmailConfig(ctx *gin.Context) {
 // 获取存储配置信息
 func GetStorageConfig(ctx *gin.Context) {
 	resp.OK(ctx, "ok", gin.H{"config": vo.StorageConfigVO{
-		MaxImgSize:         viper.GetInt("file.max_img_size"),
-		MaxVideoSize:       viper.GetInt("file.max_video_size"),
-		VideoAdaptationIos: viper.GetBool("file.video_adaptation_ios"),
+		MaxImgSize:   viper.GetInt("file.max_img_size"),
+		MaxVideoSize: viper.GetInt("file.max_video_size"),
 
 		Type:      viper.GetString("oss.type"),
 		KeyID:     viper.GetString("oss.key_id"),
@@ -63,6 +64,7 @@ func GetStorageConfig(ctx *gin.Context) {
 		Region:    viper.GetString("oss.region"),
 		Domain:    viper.GetString("oss.domain"),
 		Private:   viper.GetBool("oss.private"),
+		PathStyle: viper.GetBool("oss.path_style"),
 	}})
 }
 
@@ -79,7 +81,6 @@ func SetStorageConfig(ctx *gin.Context) {
 
 	viper.Set("file.max_img_size", storageConfigDTO.MaxImgSize)
 	viper.Set("file.max_video_size", storageConfigDTO.MaxVideoSize)
-	viper.Set("file.video_adaptation_ios", storageConfigDTO.VideoAdaptationIos)
 
 	viper.Set("oss.type", storageConfigDTO.Type)
 	viper.Set("oss.key_id", storageConfigDTO.KeyID)
@@ -89,6 +90,7 @@ func SetStorageConfig(ctx *gin.Context) {
 	viper.Set("oss.region", storageConfigDTO.Region)
 	viper.Set("oss.domain", storageConfigDTO.Domain)
 	viper.Set("oss.private", storageConfigDTO.Private)
+	viper.Set("oss.path_style", storageConfigDTO.PathStyle)
 
 	if len(storageConfigDTO.KeySecret) != 0 {
 		viper.Set("oss.key_secret", storageConfigDTO.KeySecret)
@@ -96,14 +98,102 @@ func SetStorageConfig(ctx *gin.Context) {
 
 	viper.WriteConfig()
 
-	// 重新初始化OSS
-	if storageConfigDTO.Type != "local" {
-		initialize.Oss()
+	// 重新初始化存储
+	initialize.Storage()
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
+
+// 获取转码配置信息
+func GetTranscodingConfig(ctx *gin.Context) {
+	resp.OK(ctx, "ok", gin.H{"config": vo.ToTranscodingConfigVO(service.GetEncodingConfig())})
+}
+
+// 修改转码配置
+func SetTranscodingConfig(ctx *gin.Context) {
+	var transcodingConfigDTO dto.TranscodingConfigDTO
+	if err := ctx.Bind(&transcodingConfigDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	// 参数校验
+	if len(transcodingConfigDTO.Profiles) == 0 {
+		resp.Response(ctx, resp.RequestParamError, valid.ENCODING_PROFILE_ERROR, nil)
+		zap.L().Error(valid.ENCODING_PROFILE_ERROR)
+		return
+	}
+
+	qualities := make(map[int]bool)
+	profiles := make([]map[string]interface{}, 0, len(transcodingConfigDTO.Profiles))
+	for _, p := range transcodingConfigDTO.Profiles {
+		if !valid.EncodingProfile(p) || qualities[p.Quality] {
+			resp.Response(ctx, resp.RequestParamError, valid.ENCODING_PROFILE_ERROR, nil)
+			zap.L().Error(valid.ENCODING_PROFILE_ERROR)
+			return
+		}
+		qualities[p.Quality] = true
+
+		profiles = append(profiles, map[string]interface{}{
+			"quality":    p.Quality,
+			"bitrate":    p.Bitrate,
+			"crf":        p.Crf,
+			"codec":      p.Codec,
+			"preset":     p.Preset,
+			"fps_policy": p.FpsPolicy,
+			"fps":        p.Fps,
+		})
+	}
+
+	viper.Set("transcoding.profiles", profiles)
+	viper.Set("transcoding.keep_aspect_ratio", transcodingConfigDTO.KeepAspectRatio)
+	viper.Set("transcoding.portrait", transcodingConfigDTO.Portrait)
+
+	viper.WriteConfig()
+
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
+
+// 获取存储清理配置
+func GetGcConfig(ctx *gin.Context) {
+	dryRun, gracePeriod := service.GetStorageGcConfig()
+	resp.OK(ctx, "ok", gin.H{"config": vo.GcConfigVO{
+		DryRun:      dryRun,
+		GracePeriod: gracePeriod,
+	}})
+}
+
+// 修改存储清理配置
+func SetGcConfig(ctx *gin.Context) {
+	var gcConfigDTO dto.GcConfigDTO
+	if err := ctx.Bind(&gcConfigDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
 	}
+
+	if gcConfigDTO.GracePeriod <= 0 {
+		resp.Response(ctx, resp.RequestParamError, valid.GC_GRACE_PERIOD_ERROR, nil)
+		zap.L().Error(valid.GC_GRACE_PERIOD_ERROR)
+		return
+	}
+
+	viper.Set("gc.dry_run", gcConfigDTO.DryRun)
+	viper.Set("gc.grace_period", gcConfigDTO.GracePeriod)
+
+	viper.WriteConfig()
+
 	// 返回给前端
 	resp.OK(ctx, "ok", nil)
 }
 
+// 获取存储清理报告(不删除文件)
+func GetGcReport(ctx *gin.Context) {
+	resp.OK(ctx, "ok", gin.H{"report": service.RunStorageGC(true)})
+}
+
 // 获取其他配置信息
 func GetOtherConfig(ctx *gin.Context) {
 	resp.OK(ctx, "ok", gin.H{"config": vo.OtherConfigVO{
@@ -7,6 +7,7 @@ import (
 	"clicli/domain/vo"
 	"clicli/service"
 	"clicli/util/convert"
+	"clicli/ws"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -34,15 +35,21 @@ func SendDanmaku(ctx *gin.Context) {
 
 	// 参数校验
 	if !valid.DanmakuText(danmakuDTO.Text) { //弹幕内容验证
-		resp.Response(ctx, resp.RequestParamError, valid.EMAIL_ERROR, nil)
-		zap.L().Error(valid.EMAIL_ERROR)
+		resp.Response(ctx, resp.RequestParamError, valid.DANMAKU_TEXT_ERROR, nil)
+		zap.L().Error(valid.DANMAKU_TEXT_ERROR)
 		return
 	}
 
 	video := service.GetVideoInfo(danmakuDTO.Vid)
 	if video.ID == 0 { // 验证视频是否存在
-		resp.Response(ctx, resp.VideoNotExistError, valid.DANMAKU_TEXT_ERROR, nil)
-		zap.L().Error(valid.DANMAKU_TEXT_ERROR)
+		resp.Response(ctx, resp.VideoNotExistError, "", nil)
+		zap.L().Error("视频不存在")
+		return
+	}
+
+	if service.ContainsFilterWord(danmakuDTO.Text) {
+		resp.Response(ctx, resp.ContentFilterError, "", nil)
+		zap.L().Error("弹幕包含违禁词")
 		return
 	}
 
@@ -50,11 +57,288 @@ func SendDanmaku(ctx *gin.Context) {
 		danmakuDTO.Part = 1
 	}
 
-	// 存入数据库
 	userId := ctx.GetUint("userId")
+	if service.IsDanmakuMuted(userId) {
+		resp.Response(ctx, resp.UserMutedError, "", nil)
+		zap.L().Error("用户已被禁止发送弹幕")
+		return
+	}
+
+	if !service.AllowSendDanmaku(userId) {
+		resp.Response(ctx, resp.TooManyRequestsError, "发送弹幕过于频繁", nil)
+		zap.L().Error("发送弹幕过于频繁")
+		return
+	}
+
+	// 存入数据库
 	danmaku := dto.DanmakuDtoToDanmaku(danmakuDTO, userId)
-	service.InsertDanmaku(danmaku)
+	if err := service.InsertDanmaku(danmaku); err != nil {
+		resp.Response(ctx, resp.Error, "", nil)
+		zap.L().Error("发送弹幕失败" + err.Error())
+		return
+	}
+
+	// 推送给正在观看的用户
+	ws.BroadcastDanmaku(ws.VideoRoomId(danmaku.Vid), vo.ToWsDanmakuVO(danmaku))
+
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
+
+// 删除弹幕(发送者或视频作者)
+func DeleteDanmaku(ctx *gin.Context) {
+	var idDTO dto.IdDTO
+	if err := ctx.Bind(&idDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	userId := ctx.GetUint("userId")
+	danmaku := service.SelectDanmakuByID(idDTO.ID)
+	if danmaku.ID == 0 || (danmaku.Uid != userId && service.SelectVideoAuthorId(danmaku.Vid) != userId) {
+		resp.Response(ctx, resp.DanmakuNotExistError, "", nil)
+		zap.L().Error("弹幕不存在")
+		return
+	}
+
+	if err := service.DeleteDanmaku([]uint{danmaku.ID}); err != nil {
+		resp.Response(ctx, resp.Error, "", nil)
+		zap.L().Error("删除弹幕失败" + err.Error())
+		return
+	}
+
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
+
+// 举报弹幕
+func ReportDanmaku(ctx *gin.Context) {
+	var reportDTO dto.DanmakuReportDTO
+	if err := ctx.Bind(&reportDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	if !valid.DanmakuReportReason(reportDTO.Reason) {
+		resp.Response(ctx, resp.RequestParamError, valid.DANMAKU_REPORT_ERROR, nil)
+		zap.L().Error(valid.DANMAKU_REPORT_ERROR)
+		return
+	}
+
+	danmaku := service.SelectDanmakuByID(reportDTO.ID)
+	if danmaku.ID == 0 {
+		resp.Response(ctx, resp.DanmakuNotExistError, "", nil)
+		zap.L().Error("弹幕不存在")
+		return
+	}
+
+	report := dto.DanmakuReportDtoToDanmakuReport(reportDTO, danmaku.Vid, ctx.GetUint("userId"))
+	if err := service.InsertDanmakuReport(report); err != nil {
+		resp.Response(ctx, resp.Error, "", nil)
+		zap.L().Error("举报弹幕失败" + err.Error())
+		return
+	}
+
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
+
+// 管理员搜索弹幕
+func AdminGetDanmakuList(ctx *gin.Context) {
+	vid := convert.StringToUint(ctx.DefaultQuery("vid", "0"))
+	uid := convert.StringToUint(ctx.DefaultQuery("uid", "0"))
+	keywords := ctx.Query("keywords")
+	page := convert.StringToInt(ctx.DefaultQuery("page", "1"))
+	pageSize := convert.StringToInt(ctx.DefaultQuery("page_size", "10"))
+
+	if pageSize > 30 {
+		resp.Response(ctx, resp.TooManyRequestsError, "", nil)
+		zap.L().Error("请求数量过多")
+		return
+	}
+
+	total, danmaku := service.AdminSelectDanmakuList(vid, uid, keywords, page, pageSize)
+	list := make([]vo.DanmakuManageVO, len(danmaku))
+	for i, d := range danmaku {
+		list[i] = vo.ToDanmakuManageVO(d, service.GetUserInfo(d.Uid), 0)
+	}
+
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"total": total, "danmaku": list})
+}
+
+// 管理员获取被举报的弹幕
+func AdminGetReportedDanmaku(ctx *gin.Context) {
+	page := convert.StringToInt(ctx.DefaultQuery("page", "1"))
+	pageSize := convert.StringToInt(ctx.DefaultQuery("page_size", "10"))
+
+	if pageSize > 30 {
+		resp.Response(ctx, resp.TooManyRequestsError, "", nil)
+		zap.L().Error("请求数量过多")
+		return
+	}
+
+	total, danmaku, reports := service.SelectReportedDanmaku(page, pageSize)
+	list := make([]vo.DanmakuManageVO, len(danmaku))
+	for i, d := range danmaku {
+		list[i] = vo.ToDanmakuManageVO(d, service.GetUserInfo(d.Uid), reports[d.ID])
+	}
+
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"total": total, "danmaku": list})
+}
+
+// 管理员批量删除弹幕
+func AdminDeleteDanmaku(ctx *gin.Context) {
+	var idsDTO dto.DanmakuIdsDTO
+	if err := ctx.Bind(&idsDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	if !valid.DanmakuIds(idsDTO.IDs) {
+		resp.Response(ctx, resp.RequestParamError, valid.DANMAKU_IDS_ERROR, nil)
+		zap.L().Error(valid.DANMAKU_IDS_ERROR)
+		return
+	}
+
+	if err := service.DeleteDanmaku(idsDTO.IDs); err != nil {
+		resp.Response(ctx, resp.Error, "", nil)
+		zap.L().Error("删除弹幕失败" + err.Error())
+		return
+	}
 
 	// 返回给前端
 	resp.OK(ctx, "ok", nil)
 }
+
+// 管理员忽略弹幕举报
+func AdminIgnoreDanmakuReport(ctx *gin.Context) {
+	var idDTO dto.IdDTO
+	if err := ctx.Bind(&idDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	service.DeleteDanmakuReport(idDTO.ID)
+
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
+
+// 管理员禁止用户发送弹幕
+func AdminMuteDanmakuUser(ctx *gin.Context) {
+	var muteDTO dto.DanmakuMuteDTO
+	if err := ctx.Bind(&muteDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	if service.SelectUserByID(muteDTO.Uid).ID == 0 {
+		resp.Response(ctx, resp.UserNotExistError, "", nil)
+		zap.L().Error("用户不存在")
+		return
+	}
+
+	service.MuteDanmakuUser(muteDTO.Uid, muteDTO.Hours)
+
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
+
+// 导出弹幕，format为xml或ass
+func ExportDanmaku(ctx *gin.Context) {
+	vid := convert.StringToUint(ctx.Query("vid"))
+	part := convert.StringToUint(ctx.DefaultQuery("part", "1"))
+	format := ctx.DefaultQuery("format", "xml")
+
+	if format != "xml" && format != "ass" {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("不支持的弹幕格式")
+		return
+	}
+
+	if service.GetVideoInfo(vid).ID == 0 {
+		resp.Response(ctx, resp.VideoNotExistError, "", nil)
+		zap.L().Error("视频不存在")
+		return
+	}
+
+	if !service.IsVideoPartExist(vid, part, true) {
+		resp.Response(ctx, resp.ResourceNotExistError, "", nil)
+		zap.L().Error("资源不存在")
+		return
+	}
+
+	filename := convert.UintToString(vid) + "_" + convert.UintToString(part) + "." + format
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+
+	var err error
+	if format == "ass" {
+		ctx.Header("Content-Type", "text/x-ssa; charset=utf-8")
+		err = service.ExportDanmakuASS(ctx.Writer, vid, part)
+	} else {
+		ctx.Header("Content-Type", "application/xml; charset=utf-8")
+		err = service.ExportDanmakuXML(ctx.Writer, vid, part)
+	}
+
+	if err != nil {
+		zap.L().Error("导出弹幕失败" + err.Error())
+	}
+}
+
+// 视频作者导入XML弹幕
+func ImportDanmaku(ctx *gin.Context) {
+	vid := convert.StringToUint(ctx.PostForm("vid"))
+	part := convert.StringToUint(ctx.DefaultPostForm("part", "1"))
+
+	userId := ctx.GetUint("userId")
+	if !service.IsVideoBelongUser(vid, userId) {
+		resp.Response(ctx, resp.VideoNotExistError, "", nil)
+		zap.L().Error("视频不存在")
+		return
+	}
+
+	if !service.IsVideoPartExist(vid, part, false) {
+		resp.Response(ctx, resp.ResourceNotExistError, "", nil)
+		zap.L().Error("资源不存在")
+		return
+	}
+
+	file, err := ctx.FormFile("danmaku")
+	if err != nil {
+		resp.Response(ctx, resp.FileUploadError, "", nil)
+		zap.L().Error("文件上传失败" + err.Error())
+		return
+	}
+
+	//文件大小限制
+	if !valid.FileBytes(file.Size, service.MAX_DANMAKU_FILE_SIZE) {
+		resp.Response(ctx, resp.FileCheckError, valid.FILE_SIZE_ERROR, nil)
+		zap.L().Error(valid.FILE_SIZE_ERROR)
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		resp.Response(ctx, resp.FileUploadError, "", nil)
+		zap.L().Error("文件上传失败" + err.Error())
+		return
+	}
+	defer f.Close()
+
+	count, err := service.ImportDanmakuXML(f, vid, part, userId)
+	if err != nil {
+		resp.Response(ctx, resp.FileCheckError, "弹幕文件格式有误", nil)
+		zap.L().Error("导入弹幕失败" + err.Error())
+		return
+	}
+
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"count": count})
+}
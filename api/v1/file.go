@@ -0,0 +1,60 @@
+package api
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"clicli/service"
+	"clicli/storage"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 获取签名的文件
+func GetSignedFile(ctx *gin.Context) {
+	expires := ctx.Param("expires")
+	objectKey, ok := service.VerifyFileSignature(expires, ctx.Param("signature"), ctx.Param("key"))
+	if !ok {
+		ctx.Status(http.StatusForbidden)
+		return
+	}
+
+	// 链接有效期内允许缓存
+	t, _ := strconv.ParseInt(expires, 10, 64)
+	remaining := time.Until(time.Unix(t, 0))
+	ctx.Header("Cache-Control", "private, max-age="+strconv.FormatInt(int64(remaining.Seconds()), 10))
+
+	s := storage.GetStorage()
+	if storage.IsLocal(s) {
+		ctx.File(storage.LOCAL_ROOT + "/" + objectKey)
+		return
+	}
+
+	// 远程存储的分片和图片跳转到存储的预签名链接
+	if !service.IsPlaylistFile(objectKey) {
+		url, err := s.SignUrl(objectKey, remaining)
+		if err == nil {
+			ctx.Redirect(http.StatusFound, url)
+			return
+		}
+		zap.L().Error("生成预签名链接失败" + err.Error())
+	}
+
+	// 播放列表由服务器代理，保证其中的相对路径仍然经过签名校验
+	reader, err := s.GetObject(objectKey)
+	if err != nil {
+		ctx.Status(http.StatusNotFound)
+		zap.L().Error("获取文件失败" + err.Error())
+		return
+	}
+	defer reader.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(objectKey))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	ctx.DataFromReader(http.StatusOK, -1, contentType, reader, nil)
+}
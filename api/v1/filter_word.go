@@ -0,0 +1,78 @@
+package api
+
+import (
+	"clicli/domain/dto"
+	"clicli/domain/resp"
+	"clicli/domain/valid"
+	"clicli/domain/vo"
+	"clicli/service"
+	"clicli/util/convert"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 获取违禁词列表
+func AdminGetFilterWordList(ctx *gin.Context) {
+	page := convert.StringToInt(ctx.DefaultQuery("page", "1"))
+	pageSize := convert.StringToInt(ctx.DefaultQuery("page_size", "10"))
+
+	if pageSize > 30 {
+		resp.Response(ctx, resp.TooManyRequestsError, "", nil)
+		zap.L().Error("请求数量过多")
+		return
+	}
+
+	total, words := service.SelectFilterWordList(page, pageSize)
+
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"total": total, "words": vo.ToFilterWordVoList(words)})
+}
+
+// 添加违禁词
+func AdminAddFilterWord(ctx *gin.Context) {
+	var wordDTO dto.FilterWordDTO
+	if err := ctx.Bind(&wordDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	// 参数校验
+	if !valid.FilterWord(wordDTO.Word) {
+		resp.Response(ctx, resp.RequestParamError, valid.FILTER_WORD_ERROR, nil)
+		zap.L().Error(valid.FILTER_WORD_ERROR)
+		return
+	}
+
+	if wordDTO.Regex {
+		if _, err := service.CompileFilterRegex(wordDTO.Word); err != nil {
+			resp.Response(ctx, resp.RequestParamError, valid.FILTER_REGEX_ERROR, nil)
+			zap.L().Error(valid.FILTER_REGEX_ERROR)
+			return
+		}
+	}
+
+	if err := service.InsertFilterWord(dto.FilterWordDtoToFilterWord(wordDTO)); err != nil {
+		resp.Response(ctx, resp.Error, "", nil)
+		zap.L().Error("添加违禁词失败" + err.Error())
+		return
+	}
+
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
+
+// 删除违禁词
+func AdminDeleteFilterWord(ctx *gin.Context) {
+	var idDTO dto.IdDTO
+	if err := ctx.Bind(&idDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	service.DeleteFilterWord(idDTO.ID)
+
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
@@ -0,0 +1,288 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"clicli/cache"
+	"clicli/domain/dto"
+	"clicli/domain/model"
+	"clicli/domain/resp"
+	"clicli/domain/valid"
+	"clicli/domain/vo"
+	"clicli/service"
+	"clicli/util/convert"
+	"clicli/ws"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 获取在线直播列表
+func GetLiveList(ctx *gin.Context) {
+	page := convert.StringToInt(ctx.DefaultQuery("page", "1"))
+	pageSize := convert.StringToInt(ctx.DefaultQuery("page_size", "10"))
+
+	if pageSize > 30 {
+		resp.Response(ctx, resp.TooManyRequestsError, "", nil)
+		zap.L().Error("请求数量过多")
+		return
+	}
+
+	if page < 1 || pageSize < 1 {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	total, rooms := service.SelectOnlineLiveRoom(page, pageSize)
+	lives := make([]vo.LiveRoomVO, len(rooms))
+	for i, room := range rooms {
+		flvUrl, hlsUrl := service.GetLivePlayUrl(room.StreamName)
+		lives[i] = vo.ToLiveRoomVO(room, cache.GetLiveStartTime(room.ID), flvUrl, hlsUrl)
+	}
+
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"total": total, "lives": lives})
+}
+
+// 获取直播间信息
+func GetLiveRoom(ctx *gin.Context) {
+	id := convert.StringToUint(ctx.Query("id"))
+	room := service.SelectLiveRoomByID(id)
+	if room.ID == 0 {
+		resp.Response(ctx, resp.LiveNotExistError, "", nil)
+		zap.L().Error("直播间不存在")
+		return
+	}
+
+	room.Author = service.GetUserInfo(room.Uid)
+	flvUrl, hlsUrl := service.GetLivePlayUrl(room.StreamName)
+
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"live": vo.ToLiveRoomVO(room, cache.GetLiveStartTime(room.ID), flvUrl, hlsUrl)})
+}
+
+// 获取自己的直播间(含推流信息)
+func GetOwnLiveRoom(ctx *gin.Context) {
+	userId := ctx.GetUint("userId")
+	room, err := service.GetOrCreateLiveRoom(userId)
+	if err != nil {
+		resp.Response(ctx, resp.Error, "", nil)
+		zap.L().Error("获取直播间失败" + err.Error())
+		return
+	}
+
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"live": toLiveRoomOwnerVO(room)})
+}
+
+// 重新生成推流密钥
+func RotateStreamKey(ctx *gin.Context) {
+	room, err := service.RotateStreamKey(ctx.GetUint("userId"))
+	if err != nil {
+		resp.Response(ctx, resp.Error, "", nil)
+		zap.L().Error("重新生成推流密钥失败" + err.Error())
+		return
+	}
+
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"live": toLiveRoomOwnerVO(room)})
+}
+
+// 吊销推流密钥
+func RevokeStreamKey(ctx *gin.Context) {
+	if _, err := service.RevokeStreamKey(ctx.GetUint("userId")); err != nil {
+		resp.Response(ctx, resp.Error, "", nil)
+		zap.L().Error("吊销推流密钥失败" + err.Error())
+		return
+	}
+
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
+
+func toLiveRoomOwnerVO(room model.LiveRoom) vo.LiveRoomOwnerVO {
+	room.Author = service.GetUserInfo(room.Uid)
+	flvUrl, hlsUrl := service.GetLivePlayUrl(room.StreamName)
+	return vo.LiveRoomOwnerVO{
+		LiveRoomVO: vo.ToLiveRoomVO(room, cache.GetLiveStartTime(room.ID), flvUrl, hlsUrl),
+		PushUrl:    service.GetLivePushUrl(room.StreamName, room.StreamKey),
+		StreamName: room.StreamName,
+		StreamKey:  room.StreamKey,
+	}
+}
+
+// 修改直播间信息
+func ModifyLiveRoom(ctx *gin.Context) {
+	var modifyDTO dto.ModifyLiveRoomDTO
+	if err := ctx.Bind(&modifyDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	// 参数校验
+	if !valid.Title(modifyDTO.Title) {
+		resp.Response(ctx, resp.RequestParamError, valid.TITLE_ERROR, nil)
+		zap.L().Error(valid.TITLE_ERROR)
+		return
+	}
+
+	userId := ctx.GetUint("userId")
+	room, err := service.GetOrCreateLiveRoom(userId)
+	if err != nil {
+		resp.Response(ctx, resp.Error, "", nil)
+		zap.L().Error("获取直播间失败" + err.Error())
+		return
+	}
+
+	if modifyDTO.Cover != room.Cover && cache.GetUploadImage(modifyDTO.Cover) != userId {
+		resp.Response(ctx, resp.InvalidLinkError, "", nil)
+		zap.L().Error("文件链接无效")
+		return
+	}
+
+	if err := service.ModifyLiveRoom(userId, modifyDTO); err != nil {
+		resp.Response(ctx, resp.Error, "", nil)
+		zap.L().Error("修改直播间信息失败" + err.Error())
+		return
+	}
+
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
+
+// 连接直播间websocket，接收在线人数和弹幕
+func GetLiveConnect(ctx *gin.Context) {
+	id := convert.StringToUint(ctx.Query("id"))
+	clientId := ctx.Query("client_id")
+	if id == 0 {
+		return
+	}
+
+	// 升级为websocket长链接
+	ws.RoomWsHandler(ctx.Writer, ctx.Request, ws.LiveRoomId(id), clientId)
+}
+
+// 媒体服务器开始推流回调
+func LivePublishCallback(ctx *gin.Context) {
+	var callbackDTO dto.SrsCallbackDTO
+	if !bindLiveCallback(ctx, &callbackDTO) {
+		return
+	}
+
+	if _, err := service.LivePublish(callbackDTO); err != nil {
+		rejectLiveCallback(ctx)
+		zap.L().Error("推流鉴权失败 " + callbackDTO.App + "/" + callbackDTO.Stream + " " + callbackDTO.Ip + " " + err.Error())
+		return
+	}
+
+	acceptLiveCallback(ctx)
+}
+
+// 媒体服务器停止推流回调
+func LiveUnpublishCallback(ctx *gin.Context) {
+	var callbackDTO dto.SrsCallbackDTO
+	if !bindLiveCallback(ctx, &callbackDTO) {
+		return
+	}
+
+	if _, err := service.LiveUnpublish(callbackDTO); err != nil {
+		zap.L().Error("停止推流的直播间不存在 " + callbackDTO.Stream)
+	}
+
+	// 停止推流无法拒绝，始终返回成功
+	acceptLiveCallback(ctx)
+}
+
+// 媒体服务器DVR录制完成回调
+func LiveDvrCallback(ctx *gin.Context) {
+	var callbackDTO dto.SrsCallbackDTO
+	if !bindLiveCallback(ctx, &callbackDTO) {
+		return
+	}
+
+	if err := service.LiveDvr(callbackDTO); err != nil {
+		zap.L().Error("处理直播录像失败 " + callbackDTO.Stream + " " + err.Error())
+	}
+
+	acceptLiveCallback(ctx)
+}
+
+// 发送直播弹幕
+func SendLiveDanmaku(ctx *gin.Context) {
+	var danmakuDTO dto.LiveDanmakuDTO
+	if err := ctx.Bind(&danmakuDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	// 参数校验
+	if !valid.DanmakuText(danmakuDTO.Text) {
+		resp.Response(ctx, resp.RequestParamError, valid.DANMAKU_TEXT_ERROR, nil)
+		zap.L().Error(valid.DANMAKU_TEXT_ERROR)
+		return
+	}
+
+	if service.ContainsFilterWord(danmakuDTO.Text) {
+		resp.Response(ctx, resp.ContentFilterError, "", nil)
+		zap.L().Error("弹幕包含违禁词")
+		return
+	}
+
+	userId := ctx.GetUint("userId")
+	if service.IsDanmakuMuted(userId) {
+		resp.Response(ctx, resp.UserMutedError, "", nil)
+		zap.L().Error("用户已被禁止发送弹幕")
+		return
+	}
+
+	if !service.AllowSendDanmaku(userId) {
+		resp.Response(ctx, resp.TooManyRequestsError, "发送弹幕过于频繁", nil)
+		zap.L().Error("发送弹幕过于频繁")
+		return
+	}
+
+	// 存入数据库
+	danmaku, err := service.InsertLiveDanmaku(dto.LiveDanmakuDtoToLiveDanmaku(danmakuDTO, userId))
+	if err != nil {
+		resp.Response(ctx, resp.LiveNotExistError, "", nil)
+		zap.L().Error("直播间未开播")
+		return
+	}
+
+	// 推送给直播间内的观众
+	ws.BroadcastDanmaku(ws.LiveRoomId(danmaku.RoomId), vo.LiveDanmakuToWsDanmakuVO(danmaku))
+
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
+
+// 校验回调密钥并解析回调参数
+func bindLiveCallback(ctx *gin.Context, callbackDTO *dto.SrsCallbackDTO) bool {
+	secret := viper.GetString("live.callback_secret")
+	if secret == "" || subtle.ConstantTimeCompare([]byte(ctx.Query("secret")), []byte(secret)) != 1 {
+		rejectLiveCallback(ctx)
+		zap.L().Error("直播回调密钥错误 " + ctx.ClientIP())
+		return false
+	}
+
+	if err := ctx.ShouldBindJSON(callbackDTO); err != nil {
+		rejectLiveCallback(ctx)
+		zap.L().Error("直播回调参数有误")
+		return false
+	}
+
+	return true
+}
+
+// SRS要求回调返回 code 为 0 表示允许
+func acceptLiveCallback(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"code": 0})
+}
+
+func rejectLiveCallback(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"code": 1})
+}
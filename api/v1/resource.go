@@ -5,6 +5,7 @@ import (
 	"clicli/domain/resp"
 	"clicli/domain/valid"
 	"clicli/service"
+	"clicli/util/convert"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -65,3 +66,20 @@ func DeleteResource(ctx *gin.Context) {
 	// 返回给前端
 	resp.OK(ctx, "ok", nil)
 }
+
+// 获取资源转码进度
+func GetResourceProgress(ctx *gin.Context) {
+	resourceId := convert.StringToUint(ctx.Query("rid"))
+
+	// 是否为资源作者
+	userId := ctx.GetUint("userId")
+	resource := service.SelectResourceByID(resourceId)
+	if resource.ID == 0 || resource.Uid != userId {
+		resp.Response(ctx, resp.ResourceNotExistError, "", nil)
+		zap.L().Error("资源不存在")
+		return
+	}
+
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"progress": service.GetTranscodingProgress(resource)})
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"clicli/domain/dto"
+	"clicli/domain/resp"
+	"clicli/domain/valid"
+	"clicli/service"
+	"clicli/util/convert"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 获取搜索建议
+func GetSearchSuggest(ctx *gin.Context) {
+	keywords := ctx.Query("keywords")
+
+	suggestions := service.SelectSearchSuggest(keywords)
+
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"suggestions": suggestions})
+}
+
+// 获取热门搜索
+func GetTrendingSearch(ctx *gin.Context) {
+	trending := service.SelectTrendingSearch()
+
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"trending": trending})
+}
+
+// 按搜索次数获取搜索词
+func AdminGetHotSearchList(ctx *gin.Context) {
+	page := convert.StringToInt(ctx.DefaultQuery("page", "1"))
+	pageSize := convert.StringToInt(ctx.DefaultQuery("page_size", "10"))
+
+	if pageSize > 30 {
+		resp.Response(ctx, resp.TooManyRequestsError, "", nil)
+		zap.L().Error("请求数量过多")
+		return
+	}
+
+	total, keywords := service.SelectHotSearchList(page, pageSize)
+
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"total": total, "keywords": keywords})
+}
+
+// 获取搜索建议屏蔽词
+func AdminGetSearchBlockList(ctx *gin.Context) {
+	words := service.SelectSearchBlockList()
+
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"words": words})
+}
+
+// 添加搜索建议屏蔽词
+func AdminAddSearchBlock(ctx *gin.Context) {
+	var blockDTO dto.SearchBlockDTO
+	if err := ctx.Bind(&blockDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	// 参数校验
+	if !valid.SearchBlockWord(blockDTO.Word) {
+		resp.Response(ctx, resp.RequestParamError, valid.SEARCH_BLOCK_ERROR, nil)
+		zap.L().Error(valid.SEARCH_BLOCK_ERROR)
+		return
+	}
+
+	service.InsertSearchBlock(blockDTO.Word)
+
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
+
+// 删除搜索建议屏蔽词
+func AdminDeleteSearchBlock(ctx *gin.Context) {
+	var blockDTO dto.SearchBlockDTO
+	if err := ctx.Bind(&blockDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	service.DeleteSearchBlock(blockDTO.Word)
+
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
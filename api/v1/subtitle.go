@@ -0,0 +1,177 @@
+package api
+
+import (
+	"path"
+
+	"clicli/domain/dto"
+	"clicli/domain/model"
+	"clicli/domain/resp"
+	"clicli/domain/valid"
+	"clicli/domain/vo"
+	"clicli/service"
+	"clicli/util/convert"
+	"clicli/util/subtitle"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 上传字幕，支持SRT和WebVTT，同一语言的字幕会被替换
+func UploadSubtitle(ctx *gin.Context) {
+	resourceId := convert.StringToUint(ctx.PostForm("rid"))
+	lang := ctx.PostForm("lang")
+	label := ctx.PostForm("label")
+
+	// 参数校验
+	if !valid.SubtitleLang(lang) {
+		resp.Response(ctx, resp.RequestParamError, valid.SUBTITLE_LANG_ERROR, nil)
+		zap.L().Error(valid.SUBTITLE_LANG_ERROR)
+		return
+	}
+
+	if !valid.SubtitleLabel(label) {
+		resp.Response(ctx, resp.RequestParamError, valid.SUBTITLE_LABEL_ERROR, nil)
+		zap.L().Error(valid.SUBTITLE_LABEL_ERROR)
+		return
+	}
+
+	// 是否为资源作者
+	userId := ctx.GetUint("userId")
+	resource := service.SelectResourceByID(resourceId)
+	if resource.ID == 0 || resource.Uid != userId {
+		resp.Response(ctx, resp.ResourceNotExistError, "", nil)
+		zap.L().Error("资源不存在")
+		return
+	}
+
+	file, err := ctx.FormFile("subtitle")
+	if err != nil {
+		resp.Response(ctx, resp.FileUploadError, "", nil)
+		zap.L().Error("文件上传失败" + err.Error())
+		return
+	}
+
+	if !valid.SubtitleType(path.Ext(file.Filename)) {
+		resp.Response(ctx, resp.FileCheckError, valid.FILE_TYPE_ERROR, nil)
+		zap.L().Error(valid.FILE_TYPE_ERROR)
+		return
+	}
+
+	//文件大小限制
+	if !valid.FileBytes(file.Size, service.MAX_SUBTITLE_FILE_SIZE) {
+		resp.Response(ctx, resp.FileCheckError, valid.FILE_SIZE_ERROR, nil)
+		zap.L().Error(valid.FILE_SIZE_ERROR)
+		return
+	}
+
+	data, err := readUploadedFile(file)
+	if err != nil {
+		resp.Response(ctx, resp.FileUploadError, "", nil)
+		zap.L().Error("文件上传失败" + err.Error())
+		return
+	}
+
+	// 转为WebVTT并保存
+	url, err := service.SaveSubtitle(data)
+	if err != nil {
+		if err == subtitle.ErrSubtitleFormat {
+			resp.Response(ctx, resp.FileContentError, valid.SUBTITLE_FORMAT_ERROR, nil)
+			zap.L().Error(valid.SUBTITLE_FORMAT_ERROR)
+		} else {
+			resp.Response(ctx, resp.OssError, "", nil)
+			zap.L().Error("上传存储错误:" + err.Error())
+		}
+		return
+	}
+
+	s, err := service.InsertSubtitle(model.Subtitle{
+		Rid:   resource.ID,
+		Uid:   userId,
+		Lang:  lang,
+		Label: label,
+		Url:   url,
+	})
+	if err != nil {
+		if err == service.ErrSubtitleCount {
+			resp.Response(ctx, resp.RequestParamError, valid.SUBTITLE_COUNT_ERROR, nil)
+			zap.L().Error(valid.SUBTITLE_COUNT_ERROR)
+		} else {
+			resp.Response(ctx, resp.CreateError, "", nil)
+			zap.L().Error("保存字幕失败" + err.Error())
+		}
+		return
+	}
+
+	// 返回给前端
+	s.Url = service.SignSubtitleUrl(s.Url)
+	resp.OK(ctx, "ok", gin.H{"subtitle": vo.ToSubtitleVO(s)})
+}
+
+// 修改字幕语言和名称
+func ModifySubtitle(ctx *gin.Context) {
+	var modifySubtitleDTO dto.ModifySubtitleDTO
+	if err := ctx.Bind(&modifySubtitleDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	// 参数校验
+	if !valid.SubtitleLang(modifySubtitleDTO.Lang) {
+		resp.Response(ctx, resp.RequestParamError, valid.SUBTITLE_LANG_ERROR, nil)
+		zap.L().Error(valid.SUBTITLE_LANG_ERROR)
+		return
+	}
+
+	if !valid.SubtitleLabel(modifySubtitleDTO.Label) {
+		resp.Response(ctx, resp.RequestParamError, valid.SUBTITLE_LABEL_ERROR, nil)
+		zap.L().Error(valid.SUBTITLE_LABEL_ERROR)
+		return
+	}
+
+	// 是否为字幕作者
+	userId := ctx.GetUint("userId")
+	s := service.SelectSubtitleByID(modifySubtitleDTO.ID)
+	if s.ID == 0 || s.Uid != userId {
+		resp.Response(ctx, resp.SubtitleNotExistError, "", nil)
+		zap.L().Error("字幕不存在")
+		return
+	}
+
+	if err := service.ModifySubtitle(modifySubtitleDTO); err != nil {
+		if err == service.ErrSubtitleExist {
+			resp.Response(ctx, resp.RequestParamError, valid.SUBTITLE_EXIST_ERROR, nil)
+			zap.L().Error(valid.SUBTITLE_EXIST_ERROR)
+		} else {
+			resp.Response(ctx, resp.UpdateError, "", nil)
+			zap.L().Error("修改字幕失败" + err.Error())
+		}
+		return
+	}
+
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
+
+// 删除字幕
+func DeleteSubtitle(ctx *gin.Context) {
+	var idDTO dto.IdDTO
+	if err := ctx.Bind(&idDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	// 是否为字幕作者
+	userId := ctx.GetUint("userId")
+	s := service.SelectSubtitleByID(idDTO.ID)
+	if s.ID == 0 || s.Uid != userId {
+		resp.Response(ctx, resp.SubtitleNotExistError, "", nil)
+		zap.L().Error("字幕不存在")
+		return
+	}
+
+	service.DeleteSubtitle(idDTO.ID)
+
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
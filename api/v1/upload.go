@@ -1,13 +1,17 @@
 package api
 
 import (
+	"io"
+	"mime/multipart"
 	"os"
 	"path"
+	"strings"
 
 	"clicli/cache"
 	"clicli/domain/dto"
 	"clicli/domain/resp"
 	"clicli/domain/valid"
+	"clicli/domain/vo"
 	"clicli/service"
 	"clicli/util/convert"
 	"github.com/gin-gonic/gin"
@@ -23,42 +27,57 @@ func UploadImg(ctx *gin.Context) {
 		return
 	}
 
-	suffix := path.Ext(img.Filename)
-	fileName := service.GenerateImgFilename(suffix)
 	// 参数校验
-	if !valid.FileType(suffix, true) { // 文件后缀
+	if !valid.FileType(path.Ext(img.Filename), true) { // 文件后缀
 		resp.Response(ctx, resp.FileCheckError, valid.FILE_TYPE_ERROR, nil)
 		zap.L().Error(valid.FILE_TYPE_ERROR)
 		return
 	}
 
 	//文件大小限制
-	if !valid.FileSize(ctx.GetHeader("Content-Length"), viper.GetInt64("file.max_img_size")) {
+	if !valid.FileBytes(img.Size, viper.GetInt64("file.max_img_size")) {
 		resp.Response(ctx, resp.FileCheckError, valid.FILE_SIZE_ERROR, nil)
 		zap.L().Error(valid.FILE_SIZE_ERROR)
 		return
 	}
 
-	//保存文件
-	if err := ctx.SaveUploadedFile(img, "./upload/image/"+fileName); err != nil {
-		resp.Response(ctx, resp.Error, "文件保存失败", nil)
-		zap.L().Error("文件保存失败" + err.Error())
+	data, err := readUploadedFile(img)
+	if err != nil {
+		resp.Response(ctx, resp.FileUploadError, "", nil)
+		zap.L().Error("文件上传失败" + err.Error())
 		return
 	}
 
-	url, err := service.GenerateFileUrl("image/" + fileName)
-	if err != nil {
-		resp.Response(ctx, resp.Error, "文件保存失败", nil)
-		zap.L().Error("生成url失败" + err.Error())
+	// 根据文件内容判断类型，不信任文件后缀
+	suffix, ok := valid.ImageContent(data)
+	if !ok {
+		resp.Response(ctx, resp.FileContentError, "", nil)
+		zap.L().Error(valid.FILE_CONTENT_ERROR)
 		return
 	}
 
-	if viper.GetString("oss.type") != "local" {
-		if err := service.UploadImgToOss(fileName); err != nil {
+	//重新编码并保存文件
+	fileName := service.GenerateImgFilename(suffix)
+	if err := service.SaveUploadImage(data, fileName); err != nil {
+		switch err {
+		case service.ErrImageDecode:
+			resp.Response(ctx, resp.ImageDecodeError, "", nil)
+			zap.L().Error(valid.IMAGE_DECODE_ERROR)
+		case service.ErrImageDimension:
+			resp.Response(ctx, resp.ImageDimensionError, "", nil)
+			zap.L().Error(valid.IMAGE_DIMENSION_ERROR)
+		default:
 			resp.Response(ctx, resp.OssError, "", nil)
-			zap.L().Error("上传OSS错误:" + err.Error())
-			return
+			zap.L().Error("上传存储错误:" + err.Error())
 		}
+		return
+	}
+
+	url, err := service.GenerateFileUrl("image/" + fileName)
+	if err != nil {
+		resp.Response(ctx, resp.Error, "文件保存失败", nil)
+		zap.L().Error("生成url失败" + err.Error())
+		return
 	}
 
 	// 缓存url
@@ -105,8 +124,8 @@ func UploadVideo(ctx *gin.Context) {
 		return
 	}
 
-	//文件大小限制
-	if !valid.FileSize(ctx.GetHeader("Content-Length"), viper.GetInt64("file.max_video_size")) {
+	//文件大小限制，以实际接收的字节数为准
+	if !valid.FileBytes(img.Size, viper.GetInt64("file.max_video_size")) {
 		resp.Response(ctx, resp.FileCheckError, valid.FILE_SIZE_ERROR, nil)
 		zap.L().Error(valid.FILE_SIZE_ERROR)
 		return
@@ -126,37 +145,233 @@ func UploadVideo(ctx *gin.Context) {
 		return
 	}
 
-	quality, duration, err := service.PreTreatmentVideo(uploadVideoPath)
+	if !saveVideoResource(ctx, vid, userId, filenameOnly) {
+		return
+	}
+
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
+
+// 初始化分片上传
+func InitChunkUpload(ctx *gin.Context) {
+	var initDTO dto.InitChunkUploadDTO
+	if err := ctx.Bind(&initDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	userId := ctx.GetUint("userId")
+	videoInfo := service.GetVideoInfo(initDTO.Vid)
+	if videoInfo.ID == 0 || videoInfo.Uid != userId {
+		resp.Response(ctx, resp.VideoNotExistError, "", nil)
+		zap.L().Error("视频不存在")
+		return
+	}
+
+	if !valid.FileType(path.Ext(initDTO.Filename), false) { // 文件后缀
+		resp.Response(ctx, resp.FileCheckError, valid.FILE_TYPE_ERROR, nil)
+		zap.L().Error(valid.FILE_TYPE_ERROR)
+		return
+	}
+
+	//文件大小限制
+	if !valid.FileBytes(initDTO.Size, viper.GetInt64("file.max_video_size")) {
+		resp.Response(ctx, resp.FileCheckError, valid.FILE_SIZE_ERROR, nil)
+		zap.L().Error(valid.FILE_SIZE_ERROR)
+		return
+	}
+
+	session, err := service.CreateUploadSession(initDTO.Vid, userId, initDTO.Filename, initDTO.Size)
 	if err != nil {
-		resp.Response(ctx, resp.Error, "处理视频失败", nil)
-		zap.L().Error("预处理视频失败" + err.Error())
+		resp.Response(ctx, resp.Error, "创建上传会话失败", nil)
+		zap.L().Error("创建上传会话失败" + err.Error())
 		return
 	}
 
-	// 生成url
-	url, err := service.GenerateFileUrl("video/" + filenameOnly + "/index.mpd")
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"session": vo.ToUploadSessionVO(session, []int{})})
+}
+
+// 上传分片
+func UploadChunk(ctx *gin.Context) {
+	id := ctx.PostForm("id")
+	index := convert.StringToInt(ctx.DefaultPostForm("index", "-1"))
+	hash := strings.ToLower(ctx.PostForm("hash"))
+
+	userId := ctx.GetUint("userId")
+	session := service.GetUploadSession(id)
+	if session.ID == "" || session.Uid != userId {
+		resp.Response(ctx, resp.UploadSessionNotExistError, "", nil)
+		zap.L().Error("上传会话不存在")
+		return
+	}
+
+	if !valid.ChunkHash(hash) {
+		resp.Response(ctx, resp.RequestParamError, valid.CHUNK_HASH_ERROR, nil)
+		zap.L().Error(valid.CHUNK_HASH_ERROR)
+		return
+	}
+
+	chunk, err := ctx.FormFile("chunk")
 	if err != nil {
-		resp.Response(ctx, resp.Error, "文件保存失败", nil)
-		zap.L().Error("生成url失败" + err.Error())
+		resp.Response(ctx, resp.FileUploadError, "", nil)
+		zap.L().Error("文件上传失败" + err.Error())
 		return
 	}
 
-	// 生成原始文件的url
-	originalUrl := "video/" + filenameOnly + "/upload.mp4"
-	if viper.GetBool("file.video_adaptation_ios") {
-		originalUrl, _ = service.GenerateFileUrl(originalUrl)
+	file, err := chunk.Open()
+	if err != nil {
+		resp.Response(ctx, resp.FileUploadError, "", nil)
+		zap.L().Error("文件上传失败" + err.Error())
+		return
 	}
+	defer file.Close()
 
-	// 存入数据库
-	resource := dto.ResourceDtoToResource(vid, userId, quality, duration, url, originalUrl)
-	rid := service.InsertResource(resource)
+	if err := service.SaveUploadChunk(session, index, hash, file); err != nil {
+		switch err {
+		case service.ErrChunkIndex:
+			resp.Response(ctx, resp.RequestParamError, valid.CHUNK_INDEX_ERROR, nil)
+			zap.L().Error(valid.CHUNK_INDEX_ERROR)
+		case service.ErrChunkSize:
+			resp.Response(ctx, resp.FileCheckError, valid.CHUNK_SIZE_ERROR, nil)
+			zap.L().Error(valid.CHUNK_SIZE_ERROR)
+		case service.ErrChunkHash:
+			resp.Response(ctx, resp.FileCheckError, valid.CHUNK_HASH_ERROR, nil)
+			zap.L().Error(valid.CHUNK_HASH_ERROR)
+		default:
+			resp.Response(ctx, resp.Error, "文件保存失败", nil)
+			zap.L().Error("分片保存失败" + err.Error())
+		}
+		return
+	}
 
-	// 启动转码服务
-	go service.VideoTransCoding(rid, quality, filenameOnly)
+	// 返回给前端
+	resp.OK(ctx, "ok", nil)
+}
 
-	// 记录日志
-	zap.L().Info("用户上传视频:" + filenameOnly + ",用户ID:" + convert.UintToString(userId))
+// 获取分片上传状态，用于断点续传
+func GetChunkUploadStatus(ctx *gin.Context) {
+	userId := ctx.GetUint("userId")
+	session := service.GetUploadSession(ctx.Query("id"))
+	if session.ID == "" || session.Uid != userId {
+		resp.Response(ctx, resp.UploadSessionNotExistError, "", nil)
+		zap.L().Error("上传会话不存在")
+		return
+	}
+
+	uploaded := service.SelectUploadedChunks(session)
+
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"session": vo.ToUploadSessionVO(session, uploaded)})
+}
+
+// 完成分片上传，合并分片后开始处理视频
+func CompleteChunkUpload(ctx *gin.Context) {
+	var completeDTO dto.CompleteChunkUploadDTO
+	if err := ctx.Bind(&completeDTO); err != nil {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	userId := ctx.GetUint("userId")
+	session := service.GetUploadSession(completeDTO.ID)
+	if session.ID == "" || session.Uid != userId {
+		resp.Response(ctx, resp.UploadSessionNotExistError, "", nil)
+		zap.L().Error("上传会话不存在")
+		return
+	}
+
+	videoInfo := service.GetVideoInfo(session.Vid)
+	if videoInfo.ID == 0 || videoInfo.Uid != userId {
+		resp.Response(ctx, resp.VideoNotExistError, "", nil)
+		zap.L().Error("视频不存在")
+		return
+	}
+
+	dirName, err := service.MergeUploadChunks(session)
+	if err != nil {
+		if err == service.ErrChunkLost {
+			resp.Response(ctx, resp.FileCheckError, valid.CHUNK_LOST_ERROR, nil)
+			zap.L().Error(valid.CHUNK_LOST_ERROR)
+			return
+		}
+		resp.Response(ctx, resp.Error, "文件保存失败", nil)
+		zap.L().Error("合并分片失败" + err.Error())
+		return
+	}
+
+	if !saveVideoResource(ctx, session.Vid, userId, dirName) {
+		return
+	}
 
 	// 返回给前端
 	resp.OK(ctx, "ok", nil)
 }
+
+// 预处理已保存的视频，创建资源并添加转码任务
+func saveVideoResource(ctx *gin.Context, vid, userId uint, dirName string) bool {
+	uploadVideoPath := "./upload/video/" + dirName + "/upload.mp4"
+
+	// 根据文件头判断是否为视频文件
+	if !valid.VideoContent(readFileHead(uploadVideoPath)) {
+		os.RemoveAll("./upload/video/" + dirName)
+		resp.Response(ctx, resp.FileContentError, "", nil)
+		zap.L().Error(valid.FILE_CONTENT_ERROR)
+		return false
+	}
+
+	quality, duration, err := service.PreTreatmentVideo(uploadVideoPath)
+	if err != nil {
+		os.RemoveAll("./upload/video/" + dirName)
+		switch err {
+		case service.ErrVideoDecode:
+			resp.Response(ctx, resp.VideoDecodeError, "", nil)
+			zap.L().Error(valid.VIDEO_DECODE_ERROR)
+		case service.ErrVideoDimension:
+			resp.Response(ctx, resp.VideoDimensionError, "", nil)
+			zap.L().Error(valid.VIDEO_DIMENSION_ERROR)
+		default:
+			resp.Response(ctx, resp.Error, "处理视频失败", nil)
+			zap.L().Error("预处理视频失败" + err.Error())
+		}
+		return false
+	}
+
+	// 存入数据库并添加转码任务
+	if _, err := service.CreateVideoResource(vid, userId, dirName, quality, duration); err != nil {
+		resp.Response(ctx, resp.Error, "处理视频失败", nil)
+		zap.L().Error("创建视频资源失败" + err.Error())
+		return false
+	}
+
+	// 记录日志
+	zap.L().Info("用户上传视频:" + dirName + ",用户ID:" + convert.UintToString(userId))
+	return true
+}
+
+// 读取上传的文件内容
+func readUploadedFile(fileHeader *multipart.FileHeader) ([]byte, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// 读取文件头用于判断文件类型
+func readFileHead(filePath string) []byte {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	head := make([]byte, valid.SNIFF_LENGTH)
+	n, _ := io.ReadFull(file, head)
+	return head[:n]
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"errors"
+
 	"clicli/cache"
 	"clicli/domain/dto"
 	"clicli/domain/resp"
@@ -194,6 +196,39 @@ func AdminGetUserList(ctx *gin.Context) {
 	resp.OK(ctx, "ok", gin.H{"users": vo.ToUserVoList(users), "total": total})
 }
 
+// 搜索用户
+func SearchUser(ctx *gin.Context) {
+	keywords := ctx.Query("keywords")
+	page := convert.StringToInt(ctx.DefaultQuery("page", "1"))
+	pageSize := convert.StringToInt(ctx.DefaultQuery("page_size", "15"))
+
+	if pageSize > 30 {
+		resp.Response(ctx, resp.TooManyRequestsError, "", nil)
+		zap.L().Error("请求数量过多")
+		return
+	}
+
+	if page < 1 || pageSize < 1 {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	total, users, highlights, err := service.SearchUser(keywords, page, pageSize)
+	if errors.Is(err, service.ErrSearchNotReady) {
+		resp.Response(ctx, resp.SearchNotReadyError, "", nil)
+		return
+	}
+	if err != nil {
+		resp.Response(ctx, resp.SelectError, "", nil)
+		zap.L().Error("搜索用户失败" + err.Error())
+		return
+	}
+
+	// 返回给前端
+	resp.OK(ctx, "ok", gin.H{"total": total, "users": vo.ToSearchUserVoList(users, highlights)})
+}
+
 func AdminSearchUserInfo(ctx *gin.Context) {
 	keyword := ctx.Query("keyword")
 	page := convert.StringToInt(ctx.DefaultQuery("page", "1"))
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"errors"
+
 	"clicli/cache"
 	"clicli/common"
 	"clicli/domain/dto"
@@ -11,66 +13,10 @@ import (
 	"clicli/service"
 	"clicli/util/convert"
 	"clicli/ws"
-	"encoding/json"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"io"
-	"net/http"
-	"strconv"
-	"strings"
 )
 
-type UtilsFun struct {
-	Code    int               `json:"code"`
-	Server  string            `json:"server"`
-	Service string            `json:"service"`
-	Pid     string            `json:"pid"`
-	Streams []UtilsFunStreams `json:"streams"`
-}
-
-type UtilsFunStreams struct {
-	Id         string                 `json:"id"`
-	Name       string                 `json:"name"`
-	Vhost      string                 `json:"vhost"`
-	App        string                 `json:"app"`
-	TcUrl      string                 `json:"tcUrl"`
-	Url        string                 `json:"url"`
-	Live_ms    int                    `json:"live_ms"`
-	Clients    int                    `json:"clients"`
-	Frames     int                    `json:"frames"`
-	Send_bytes int                    `json:"send_bytes"`
-	Recv_bytes int                    `json:"recv_bytes"`
-	Kbps       UtilsFunStreamsKbps    `json:"kbps"`
-	Publish    UtilsFunStreamsPublish `json:"publish"`
-	Video      UtilsFunStreamsVideo   `json:"video"`
-	Audio      UtilsFunStreamsAudio   `json:"audio"`
-}
-
-type UtilsFunStreamsKbps struct {
-	Recv_30s int `json:"recv_30s"`
-	Send_30s int `json:"send_30s"`
-}
-
-type UtilsFunStreamsPublish struct {
-	Active bool   `json:"active"`
-	Cid    string `json:"cid"`
-}
-
-type UtilsFunStreamsVideo struct {
-	Codec   string `json:"codec"`
-	Profile string `json:"profile"`
-	Level   string `json:"level"`
-	Width   int    `json:"width"`
-	Height  int    `json:"height"`
-}
-
-type UtilsFunStreamsAudio struct {
-	Codec       string `json:"codec"`
-	Sample_rate int    `json:"sample_rate"`
-	Channel     int    `json:"channel"`
-	Profile     string `json:"profile"`
-}
-
 // 上传视频信息
 func UploadVideoInfo(ctx *gin.Context) {
 	var uploadVideoDTO dto.UploadVideoDTO
@@ -132,18 +78,20 @@ func ModifyVideoInfo(ctx *gin.Context) {
 	userId := ctx.GetUint("userId")
 	oldVideoInfo := service.GetVideoInfo(modifyVideoDTO.VID)
 	if oldVideoInfo.Uid != userId {
-		if modifyVideoDTO.Cover != oldVideoInfo.Cover && cache.GetUploadImage(modifyVideoDTO.Cover) != userId {
-			resp.Response(ctx, resp.VideoNotExistError, "", nil)
-			zap.L().Error("视频不存在")
-			return
-		}
+		resp.Response(ctx, resp.VideoNotExistError, "", nil)
+		zap.L().Error("视频不存在")
+		return
 	}
 
-	// 校验封面图文件是否有效
+	// 校验封面图文件是否有效，不是上传的图片时检查是否为自动生成的候选封面
 	if modifyVideoDTO.Cover != oldVideoInfo.Cover && cache.GetUploadImage(modifyVideoDTO.Cover) != userId {
-		resp.Response(ctx, resp.InvalidLinkError, "", nil)
-		zap.L().Error("文件链接无效")
-		return
+		cover, err := service.UseGeneratedCover(modifyVideoDTO.VID, modifyVideoDTO.Cover)
+		if err != nil {
+			resp.Response(ctx, resp.InvalidLinkError, "", nil)
+			zap.L().Error("文件链接无效")
+			return
+		}
+		modifyVideoDTO.Cover = cover
 	}
 
 	// 保存到数据库
@@ -158,6 +106,7 @@ func GetVideoStatus(ctx *gin.Context) {
 	video := service.GetVideoInfo(videoId)
 
 	resources := service.SelectResourceByVideo(videoId, false)
+	resources = service.SignResources(resources, ctx.GetUint("userId"))
 
 	// 返回给前端
 	resp.OK(ctx, "ok", gin.H{"video": vo.ToVideoStatusVO(video, resources)})
@@ -165,7 +114,6 @@ func GetVideoStatus(ctx *gin.Context) {
 
 // 获取视频信息
 func GetVideoByID(ctx *gin.Context) {
-	ios := convert.StringToUint(ctx.DefaultQuery("ios", "0"))
 	vid := convert.StringToUint(ctx.DefaultQuery("vid", "0"))
 
 	video := service.GetVideoInfo(vid)
@@ -180,6 +128,7 @@ func GetVideoByID(ctx *gin.Context) {
 
 	//获取视频资源
 	resources := service.SelectResourceByVideo(video.ID, true)
+	resources = service.SignResources(resources, 0)
 
 	//增加播放量(一个ip在同一个视频下，每30分钟可重新增加1播放量)
 	service.AddVideoClicks(video.ID, ctx.ClientIP())
@@ -188,7 +137,7 @@ func GetVideoByID(ctx *gin.Context) {
 	video.Clicks = service.GetVideoClicks(video.ID)
 
 	// 返回给前端
-	resp.OK(ctx, "ok", gin.H{"video": vo.ToVideoVO(video, resources, ios)})
+	resp.OK(ctx, "ok", gin.H{"video": vo.ToVideoVO(video, resources)})
 }
 
 // 提交审核
@@ -214,50 +163,6 @@ func SubmitReview(ctx *gin.Context) {
 	resp.OK(ctx, "ok", nil)
 }
 
-// 提交密钥
-func SubmitFlvkey(ctx *gin.Context) {
-
-	var idDTO dto.IDflv
-	zap.L().Info(strconv.Itoa(int(idDTO.ID)))
-	zap.L().Info("ID")
-	if err := ctx.Bind(&idDTO); err != nil {
-		resp.Response(ctx, resp.RequestParamError, "", nil)
-		zap.L().Error("请求参数有误")
-		return
-	}
-
-	if idDTO.Flvkey == "" {
-		resp.Response(ctx, resp.KeyNotExistError, "", nil)
-		zap.L().Error("密钥为空")
-		return
-	}
-
-	videoInfo := service.GetVideoInfo(idDTO.ID)
-
-	videoInfo.Flv = "http://ctguqmx.run:8080/live/livestream/" + idDTO.Flvkey + ".flv"
-	service.UpdateVideoFlv(videoInfo)
-
-	// 更新视频状态
-	service.UpadteVideoStatus(idDTO.ID, common.WAITING_REVIEW)
-
-	// 返回给前端
-	resp.OK(ctx, "ok", nil)
-}
-
-func GetVideoFlv(ctx *gin.Context) {
-	var idDTO dto.IdDTO
-
-	if err := ctx.Bind(&idDTO); err != nil {
-		resp.Response(ctx, resp.RequestParamError, "", nil)
-		zap.L().Error("请求参数有误")
-		return
-	}
-
-	videoInfo := service.GetVideoInfo(idDTO.ID)
-
-	resp.OK(ctx, "ok", gin.H{"url": videoInfo.Flv})
-}
-
 // 获取收藏视频列表
 func GetCollectVideo(ctx *gin.Context) {
 	id := convert.StringToUint(ctx.DefaultQuery("id", "0"))
@@ -400,9 +305,8 @@ func GetRecommendedVideo(ctx *gin.Context) {
 		return
 	}
 
-	// 没有推荐功能，按点击量查询视频（点击量不是实时数据）
-	videos := service.SelectVideoListByClicks(pageSize)
-	//zap.L().Error(strconv.Itoa(videos[0].Video))
+	// 未登录时userId为0，返回热门视频
+	videos := service.SelectRecommendedVideo(ctx.GetUint("userId"), pageSize)
 	// 更新播放量数据和作者信息
 	for i := 0; i < len(videos); i++ {
 		videos[i].Clicks = service.GetVideoClicks(videos[i].ID)
@@ -412,11 +316,10 @@ func GetRecommendedVideo(ctx *gin.Context) {
 	resp.OK(ctx, "ok", gin.H{"videos": vo.ToSearchVideoVoList(videos)})
 }
 
-// 搜索视频
-func SearchVideo(ctx *gin.Context) {
-	keywords := ctx.Query("keywords")
-	page := convert.StringToInt(ctx.Query("page"))
-	pageSize := convert.StringToInt(ctx.DefaultQuery("page_size", "15"))
+// 获取相关视频
+func GetRelatedVideo(ctx *gin.Context) {
+	vid := convert.StringToUint(ctx.DefaultQuery("vid", "0"))
+	pageSize := convert.StringToInt(ctx.DefaultQuery("page_size", "10"))
 
 	if pageSize > 30 {
 		resp.Response(ctx, resp.TooManyRequestsError, "", nil)
@@ -424,152 +327,21 @@ func SearchVideo(ctx *gin.Context) {
 		return
 	}
 
-	var videos []model.Video
-	if len(keywords) == 0 {
-		_, videos = service.SelectVideoListByStatus(page, pageSize, common.AUDIT_APPROVED)
-	} else {
-		// 直接用mysql模糊查询，之后可能会更换为es
-		videos = service.SelectVideoListByKeywords(keywords, page, pageSize)
-	}
-
-	// 更新播放量数据和作者信息
-	for i := 0; i < len(videos); i++ {
-		videos[i].Clicks = service.GetVideoClicks(videos[i].ID)
-		videos[i].Author = service.GetUserInfo(videos[i].Uid)
-	}
-
-	// 返回给前端
-	resp.OK(ctx, "ok", gin.H{"videos": vo.ToSearchVideoVoList(videos)})
-}
-
-// 获取直播
-func GetUserLikeList(ctx *gin.Context) {
-	pageSize := convert.StringToInt(ctx.DefaultQuery("page_size", "15"))
-
-	if pageSize > 30 {
-		resp.Response(ctx, resp.TooManyRequestsError, "", nil)
-		zap.L().Error("请求数量过多 ")
+	video := service.GetVideoInfo(vid)
+	if video.ID == 0 || video.Status != common.AUDIT_APPROVED {
+		resp.Response(ctx, resp.VideoNotExistError, "", nil)
+		zap.L().Error("视频不存在")
 		return
 	}
 
-	// 没有推荐功能，按点击量查询视频（点击量不是实时数据）
-	videos := service.SelectLikeListByClicks(pageSize)
+	videos := service.SelectRelatedVideo(video.ID, pageSize)
 	// 更新播放量数据和作者信息
 	for i := 0; i < len(videos); i++ {
 		videos[i].Clicks = service.GetVideoClicks(videos[i].ID)
 		videos[i].Author = service.GetUserInfo(videos[i].Uid)
 	}
-
-	var likes []model.Video
-
-	for f := 0; f < len(videos); f++ {
-		if getLike(videos[f].ID) == 1 {
-			l := append(likes, videos[f])
-			likes = l
-		}
-	}
-	// 返回给前端
-	resp.OK(ctx, "ok", gin.H{"videos": vo.ToSearchVideoVoList(likes)})
-}
-
-func getLike(id uint) int {
-
-	rsps, err := http.Get("http://api.ctguqmx.run:1985/api/v1/streams")
-	if err != nil {
-		zap.L().Error("请求SRS失败" + err.Error())
-		return 0
-	}
-	defer rsps.Body.Close()
-
-	rspsbody, err := io.ReadAll(rsps.Body)
-
-	if err != nil {
-		zap.L().Error("解析请求错误" + err.Error())
-		return 0
-	}
-
-	var repsbody UtilsFun
-
-	if err := json.Unmarshal([]byte(rspsbody), &repsbody); err == nil {
-	}
-
-	videoInfo := service.GetVideoInfo(id)
-
-	flv := videoInfo.Flv
-
-	m := strings.Split(flv, "http://ctguqmx.run:8080/live/livestream/")
-	m = strings.Split(m[1], ".flv")
-	flv = m[0]
-
-	for f := 0; f < len(repsbody.Streams); f++ {
-		if flv == repsbody.Streams[f].Name {
-			return 1
-		}
-	}
-
-	return 0
-}
-
-func GetLike(ctx *gin.Context) {
-	var reviewDTO dto.LikeDTO
-	if err := ctx.Bind(&reviewDTO); err != nil {
-		resp.Response(ctx, resp.RequestParamError, "", nil)
-		zap.L().Error("请求参数有误")
-		return
-	}
-
-	rsps, err := http.Get("http://api.ctguqmx.run:1985/api/v1/streams")
-	if err != nil {
-		resp.Response(ctx, resp.LikeNotExistError, "", nil)
-		zap.L().Error("请求SRS失败" + err.Error())
-		return
-	}
-	defer rsps.Body.Close()
-
-	rspsbody, err := io.ReadAll(rsps.Body)
-
-	if err != nil {
-		resp.Response(ctx, resp.LikeNotExistError, "", nil)
-		zap.L().Error("解析请求错误" + err.Error())
-		return
-	}
-
-	var repsbody UtilsFun
-
-	if err := json.Unmarshal([]byte(rspsbody), &repsbody); err == nil {
-	}
-
-	videoInfo := service.GetVideoInfo(reviewDTO.ID)
-
-	flv := videoInfo.Flv
-	m := strings.Split(flv, "http://ctguqmx.run:8080/live/livestream/")
-	m = strings.Split(m[1], ".flv")
-	flv = m[0]
-
-	for f := 0; f < len(repsbody.Streams); f++ {
-		if flv == repsbody.Streams[f].Name {
-			resp.OK(ctx, "ok", nil)
-		}
-	}
-
-	resp.Response(ctx, resp.LikeNotExistError, "", nil)
-	return
-}
-
-func GetReviewLiveList(ctx *gin.Context) {
-	page := convert.StringToInt(ctx.Query("page"))
-	pageSize := convert.StringToInt(ctx.Query("page_size"))
-
-	if pageSize > 30 {
-		resp.Response(ctx, resp.TooManyRequestsError, "", nil)
-		zap.L().Error("请求数量过多 ")
-		return
-	}
-
-	total, videos := service.SelectLiveListByStatus(page, pageSize, common.WAITING_REVIEW)
-
 	// 返回给前端
-	resp.OK(ctx, "ok", gin.H{"total": total, "videos": vo.ToSearchVideoVoList(videos)})
+	resp.OK(ctx, "ok", gin.H{"videos": vo.ToSearchVideoVoList(videos)})
 }
 
 // 获取待审核视频列表
@@ -586,17 +358,6 @@ func GetReviewVideoList(ctx *gin.Context) {
 
 	total, videos := service.SelectVideoListByStatus(page, pageSize, common.WAITING_REVIEW)
 
-	var m []model.Video
-
-	for f := 0; f < len(videos); f++ {
-		if videos[f].Video == 1 {
-			n := append(m, videos[f])
-			m = n
-		}
-	}
-
-	videos = m
-
 	// 返回给前端
 	resp.OK(ctx, "ok", gin.H{"total": total, "videos": vo.ToSearchVideoVoList(videos)})
 }
@@ -656,6 +417,7 @@ func GetReviewVideoByID(ctx *gin.Context) {
 	vid := convert.StringToUint(ctx.Query("vid"))
 
 	resources := service.SelectResourceByVideo(vid, false)
+	resources = service.SignResources(resources, ctx.GetUint("userId"))
 
 	// 返回给前端
 	resp.OK(ctx, "ok", gin.H{"resources": vo.ToResourceVoList(resources)})
@@ -696,19 +458,66 @@ func AdminGetVideoList(ctx *gin.Context) {
 	resp.OK(ctx, "ok", gin.H{"total": total, "videos": vo.ToSearchVideoVoList(videos)})
 }
 
-// 管理员搜索视频
+// 管理员搜索视频，与用户搜索的条件相同
 func AdminSearchVideo(ctx *gin.Context) {
-	keywords := ctx.Query("keywords")
-	page := convert.StringToInt(ctx.Query("page"))
-	pageSize := convert.StringToInt(ctx.Query("page_size"))
+	searchVideo(ctx, false)
+}
 
-	if pageSize > 30 {
+// 按关键词、筛选条件和排序方式搜索视频，返回分面统计
+func SearchVideo(ctx *gin.Context) {
+	searchVideo(ctx, true)
+}
+
+// record: 是否记录搜索词，后台搜索不计入热门搜索
+func searchVideo(ctx *gin.Context, record bool) {
+	searchDTO := dto.SearchVideoDTO{
+		Keywords:  ctx.Query("keywords"),
+		Partition: convert.StringToUint(ctx.DefaultQuery("partition", "0")),
+		Duration:  convert.StringToInt(ctx.DefaultQuery("duration", "0")),
+		Copyright: convert.StringToInt(ctx.DefaultQuery("copyright", "0")),
+		BeginTime: ctx.Query("begin_time"),
+		EndTime:   ctx.Query("end_time"),
+		Sort:      ctx.Query("sort"),
+		Page:      convert.StringToInt(ctx.DefaultQuery("page", "1")),
+		PageSize:  convert.StringToInt(ctx.DefaultQuery("page_size", "15")),
+	}
+
+	if searchDTO.PageSize > 30 {
 		resp.Response(ctx, resp.TooManyRequestsError, "", nil)
 		zap.L().Error("请求数量过多 ")
 		return
 	}
 
-	total, videos := service.AdminSelectVideoListByKeywords(keywords, page, pageSize)
+	if searchDTO.Page < 1 || searchDTO.PageSize < 1 {
+		resp.Response(ctx, resp.RequestParamError, "", nil)
+		zap.L().Error("请求参数有误")
+		return
+	}
+
+	// 参数校验
+	if !valid.SearchDuration(searchDTO.Duration) || !valid.SearchCopyright(searchDTO.Copyright) ||
+		!valid.SearchSort(searchDTO.Sort) || !valid.SearchDate(searchDTO.BeginTime) || !valid.SearchDate(searchDTO.EndTime) {
+		resp.Response(ctx, resp.RequestParamError, valid.SEARCH_PARAM_ERROR, nil)
+		zap.L().Error(valid.SEARCH_PARAM_ERROR)
+		return
+	}
+
+	// 只记录第一页的搜索，翻页不重复计数
+	if record && searchDTO.Keywords != "" && searchDTO.Page == 1 {
+		service.RecordSearch(searchDTO.Keywords, ctx.ClientIP())
+	}
+
+	// 没有关键词时按发布时间和热度排序
+	total, videos, highlights, facets, err := service.SearchVideo(searchDTO)
+	if errors.Is(err, service.ErrSearchNotReady) {
+		resp.Response(ctx, resp.SearchNotReadyError, "", nil)
+		return
+	}
+	if err != nil {
+		resp.Response(ctx, resp.SelectError, "", nil)
+		zap.L().Error("搜索视频失败" + err.Error())
+		return
+	}
 
 	// 更新播放量数据和作者信息
 	for i := 0; i < len(videos); i++ {
@@ -716,8 +525,13 @@ func AdminSearchVideo(ctx *gin.Context) {
 		videos[i].Author = service.GetUserInfo(videos[i].Uid)
 	}
 
+	searchVideos := vo.ToSearchVideoVoList(videos)
+	for i := range searchVideos {
+		searchVideos[i].Highlight = highlights[i]
+	}
+
 	// 返回给前端
-	resp.OK(ctx, "ok", gin.H{"total": total, "videos": vo.ToSearchVideoVoList(videos)})
+	resp.OK(ctx, "ok", gin.H{"total": total, "videos": searchVideos, "facets": facets})
 }
 
 // 删除视频
@@ -744,5 +558,5 @@ func GetRoomConnect(ctx *gin.Context) {
 	}
 
 	// 升级为websocket长链接
-	ws.RoomWsHandler(ctx.Writer, ctx.Request, vid, clientId)
+	ws.RoomWsHandler(ctx.Writer, ctx.Request, ws.VideoRoomId(vid), clientId)
 }
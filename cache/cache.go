@@ -36,10 +36,40 @@ func Del(key string) {
 	redisClient.Del(ctx, key)
 }
 
+// 设置过期时间
+func Expire(key string, expiration time.Duration) {
+	redisClient.Expire(ctx, key, expiration)
+}
+
+// key是否存在
+func Exists(key string) bool {
+	return redisClient.Exists(ctx, key).Val() > 0
+}
+
 func Incr(key string) {
 	redisClient.Incr(ctx, key)
 }
 
+// key不存在时设置，设置成功返回true
+func SetNX(key string, value interface{}, expiration time.Duration) bool {
+	return redisClient.SetNX(ctx, key, value, expiration).Val()
+}
+
+// 值相等时删除key，用于释放自己持有的锁
+func DelIfEqual(key, value string) {
+	script := "if redis.call('get', KEYS[1]) == ARGV[1] then return redis.call('del', KEYS[1]) end return 0"
+	redisClient.Eval(ctx, script, []string{key}, value)
+}
+
+// 自增并在第一次自增时设置过期时间，返回自增后的值
+func IncrWithExpire(key string, expiration time.Duration) int64 {
+	count := redisClient.Incr(ctx, key).Val()
+	if count == 1 {
+		redisClient.Expire(ctx, key, expiration)
+	}
+	return count
+}
+
 func Keys(key string) []string {
 	return redisClient.Keys(ctx, key).Val()
 }
@@ -63,7 +93,92 @@ func ZScore(key string, member string) float64 {
 	return redisClient.ZScore(ctx, key, member).Val()
 }
 
+// 按分数从高到低获取有序集合指定区间的成员
+func ZRevRange(key string, start, stop int64) []string {
+	return redisClient.ZRevRange(ctx, key, start, stop).Val()
+}
+
+// 移除有序集合中的成员
+func ZRem(key string, members ...interface{}) {
+	redisClient.ZRem(ctx, key, members...)
+}
+
 // 移除有序集中指定排名区间内的所有成员
 func ZRemRangeByRank(key string, start, stop int64) {
 	redisClient.ZRemRangeByRank(ctx, key, start, stop)
 }
+
+// 有序集合成员的分数增加increment
+func ZIncrBy(key string, increment float64, member string) {
+	redisClient.ZIncrBy(ctx, key, increment, member)
+}
+
+// 按分数从高到低获取有序集合指定区间的成员和分数
+func ZRevRangeWithScores(key string, start, stop int64) []redis.Z {
+	return redisClient.ZRevRangeWithScores(ctx, key, start, stop).Val()
+}
+
+// 按字典序获取有序集合指定区间的成员(成员分数需要相同)
+func ZRangeByLex(key, min, max string, count int64) []string {
+	return redisClient.ZRangeByLex(ctx, key, &redis.ZRangeBy{Min: min, Max: max, Count: count}).Val()
+}
+
+// 按权重合并多个有序集合并保存到dest
+func ZUnionStore(dest string, keys []string, weights []float64) {
+	redisClient.ZUnionStore(ctx, dest, &redis.ZStore{Keys: keys, Weights: weights})
+}
+
+// 重命名key
+func Rename(key, newKey string) {
+	redisClient.Rename(ctx, key, newKey)
+}
+
+// 向集合添加成员
+func SAdd(key string, members ...interface{}) {
+	redisClient.SAdd(ctx, key, members...)
+}
+
+// 移除集合成员
+func SRem(key string, members ...interface{}) {
+	redisClient.SRem(ctx, key, members...)
+}
+
+// 获取集合所有成员
+func SMembers(key string) []string {
+	return redisClient.SMembers(ctx, key).Val()
+}
+
+// 随机移除并返回最多count个集合成员
+func SPopN(key string, count int64) []string {
+	return redisClient.SPopN(ctx, key, count).Val()
+}
+
+// 设置哈希表字段
+func HSet(key, field string, value interface{}) {
+	redisClient.HSet(ctx, key, field, value)
+}
+
+// 获取哈希表所有字段
+func HGetAll(key string) map[string]string {
+	return redisClient.HGetAll(ctx, key).Val()
+}
+
+// 删除哈希表字段
+func HDel(key string, fields ...string) {
+	redisClient.HDel(ctx, key, fields...)
+}
+
+// 发布消息
+func Publish(channel string, message interface{}) error {
+	return redisClient.Publish(ctx, channel, message).Err()
+}
+
+// 订阅频道，每条消息调用一次handler
+func Subscribe(channel string, handler func(payload string)) {
+	pubsub := redisClient.Subscribe(ctx, channel)
+	go func() {
+		for msg := range pubsub.Channel() {
+			handler(msg.Payload)
+		}
+	}()
+}
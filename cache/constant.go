@@ -86,3 +86,117 @@ const RESET_PWD_CHECK_KEY = "reset_pwd_check_key:"
 
 // 重置密码验证状态过期时间 n 分钟
 const RESET_PWD_CHECK_EXPRIRATION_TIME = 30
+
+// 转码进度缓存标识符
+const TRANSCODING_PROGRESS_KEY = "transcoding_progress_key:"
+
+// 转码进度过期时间 n 小时
+const TRANSCODING_PROGRESS_EXPRIRATION_TIME = 24
+
+// 分片上传会话缓存标识符
+const UPLOAD_SESSION_KEY = "upload_session_key:"
+
+// 分片上传会话过期时间 n 小时(每次上传分片后刷新)
+const UPLOAD_SESSION_EXPRIRATION_TIME = 24
+
+// 在线直播间有序集合(分数为开播时间)
+const LIVE_ONLINE_KEY = "live_online_key"
+
+// 直播间推流客户端标识符
+const LIVE_CLIENT_KEY = "live_client_key:"
+
+// 弹幕发送频率标识符
+const DANMAKU_RATE_KEY = "danmaku_rate_key:"
+
+// 弹幕发送频率统计窗口 n 秒
+const DANMAKU_RATE_EXPRIRATION_TIME = 10
+
+// websocket跨节点消息频道
+const WS_CHANNEL = "ws_channel"
+
+// 搜索索引更新频道
+const SEARCH_INDEX_CHANNEL = "search_index_channel"
+
+// websocket节点存活标识符
+const WS_NODE_KEY = "ws_node_key:"
+
+// websocket节点存活过期时间 n 秒(节点定时刷新)
+const WS_NODE_EXPRIRATION_TIME = 30
+
+// 房间各节点在线人数哈希表标识符
+const WS_ROOM_ONLINE_KEY = "ws_room_online_key:"
+
+// 禁止发送弹幕标识符
+const DANMAKU_MUTE_KEY = "danmaku_mute_key:"
+
+// 违禁词版本标识符(修改违禁词后各节点重新加载)
+const FILTER_WORD_VERSION_KEY = "filter_word_version_key"
+
+// 搜索词累计次数有序集合
+const SEARCH_COUNT_KEY = "search_count_key"
+
+// 保留的搜索词数量
+const SEARCH_COUNT_LIMIT = 10000
+
+// 每小时搜索次数有序集合标识符(后缀为小时数)
+const SEARCH_HOURLY_KEY = "search_hourly_key:"
+
+// 每小时搜索次数过期时间 n 小时
+const SEARCH_HOURLY_EXPRIRATION_TIME = 48
+
+// 热门搜索有序集合(按时间衰减合并每小时的搜索次数)
+const SEARCH_TRENDING_KEY = "search_trending_key"
+
+// 热门搜索过期时间 n 分钟
+const SEARCH_TRENDING_EXPRIRATION_TIME = 5
+
+// 搜索建议有序集合(按字典序前缀匹配)
+const SEARCH_SUGGEST_KEY = "search_suggest_key"
+
+// 搜索建议分数有序集合
+const SEARCH_SUGGEST_SCORE_KEY = "search_suggest_score_key"
+
+// 搜索建议屏蔽词集合
+const SEARCH_BLOCK_KEY = "search_block_key"
+
+// 点赞数或播放量变化、需要更新搜索索引的视频集合
+const SEARCH_STATS_KEY = "search_stats_key"
+
+// 重建搜索建议的锁
+const SEARCH_SUGGEST_LOCK_KEY = "search_suggest_lock_key"
+
+// 重建搜索建议的锁过期时间 n 分钟
+const SEARCH_SUGGEST_LOCK_EXPRIRATION_TIME = 10
+
+// 搜索频率标识符(后缀为客户端IP)
+const SEARCH_RATE_KEY = "search_rate_key:"
+
+// 搜索频率统计窗口 n 秒
+const SEARCH_RATE_EXPRIRATION_TIME = 60
+
+// 客户端已记录的搜索词标识符(后缀为客户端IP和搜索词)
+const SEARCH_RECORD_KEY = "search_record_key:"
+
+// 同一客户端重复搜索不计数的时间 n 小时
+const SEARCH_RECORD_EXPRIRATION_TIME = 1
+
+// 用户推荐视频有序集合标识符(后缀为用户ID)
+const RECOMMEND_USER_KEY = "recommend_user_key:"
+
+// 用户推荐视频过期时间 n 小时
+const RECOMMEND_USER_EXPRIRATION_TIME = 48
+
+// 热门视频有序集合，用于未登录用户和推荐数量不足时补充
+const RECOMMEND_POPULAR_KEY = "recommend_popular_key"
+
+// 相关视频有序集合标识符(后缀为视频ID)
+const RELATED_VIDEO_KEY = "related_video_key:"
+
+// 相关视频过期时间 n 小时
+const RELATED_VIDEO_EXPRIRATION_TIME = 6
+
+// 没有相关视频时写入的占位成员，避免每次请求都重新计算
+const RELATED_VIDEO_EMPTY_MEMBER = "empty"
+
+// 没有相关视频时占位成员的过期时间 n 分钟
+const RELATED_VIDEO_EMPTY_EXPRIRATION_TIME = 10
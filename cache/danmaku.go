@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"time"
+
+	"clicli/util/convert"
+)
+
+// 统计窗口内用户发送弹幕的次数
+func IncrDanmakuRate(userId uint) int64 {
+	return IncrWithExpire(DANMAKU_RATE_KEY+convert.UintToString(userId), time.Second*DANMAKU_RATE_EXPRIRATION_TIME)
+}
+
+// 禁止用户发送弹幕
+func SetDanmakuMute(userId uint, expiration time.Duration) {
+	Set(DANMAKU_MUTE_KEY+convert.UintToString(userId), 1, expiration)
+}
+
+// 解除禁言
+func DelDanmakuMute(userId uint) {
+	Del(DANMAKU_MUTE_KEY + convert.UintToString(userId))
+}
+
+// 用户是否被禁止发送弹幕
+func IsDanmakuMuted(userId uint) bool {
+	return Exists(DANMAKU_MUTE_KEY + convert.UintToString(userId))
+}
+
+// 违禁词版本
+func GetFilterWordVersion() string {
+	return Get(FILTER_WORD_VERSION_KEY)
+}
+
+// 违禁词修改后更新版本
+func IncrFilterWordVersion() {
+	Incr(FILTER_WORD_VERSION_KEY)
+}
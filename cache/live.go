@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"time"
+
+	"clicli/util/convert"
+)
+
+// 直播间开播
+func SetLiveOnline(roomId uint, clientId string) {
+	ZAdd(LIVE_ONLINE_KEY, float64(time.Now().Unix()), convert.UintToString(roomId))
+	Set(LIVE_CLIENT_KEY+convert.UintToString(roomId), clientId, 0)
+}
+
+// 直播间下播
+func DelLiveOnline(roomId uint) {
+	ZRem(LIVE_ONLINE_KEY, convert.UintToString(roomId))
+	Del(LIVE_CLIENT_KEY + convert.UintToString(roomId))
+}
+
+// 获取直播间当前的推流客户端
+func GetLiveClient(roomId uint) string {
+	return Get(LIVE_CLIENT_KEY + convert.UintToString(roomId))
+}
+
+// 直播间开播时间，未开播返回0
+func GetLiveStartTime(roomId uint) int64 {
+	return int64(ZScore(LIVE_ONLINE_KEY, convert.UintToString(roomId)))
+}
+
+// 分页获取在线直播间ID(按开播时间倒序)
+func GetOnlineLive(page, pageSize int) (total int64, ids []uint) {
+	total = ZCard(LIVE_ONLINE_KEY)
+	start := int64((page - 1) * pageSize)
+	members := ZRevRange(LIVE_ONLINE_KEY, start, start+int64(pageSize)-1)
+	ids = make([]uint, len(members))
+	for i, member := range members {
+		ids[i] = convert.StringToUint(member)
+	}
+	return
+}
+
+// 获取所有在线直播间ID
+func GetAllOnlineLive() []uint {
+	_, ids := GetOnlineLive(1, int(ZCard(LIVE_ONLINE_KEY)))
+	return ids
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"strconv"
+	"time"
+
+	"clicli/util/convert"
+	"github.com/go-redis/redis/v9"
+)
+
+// 保存用户的推荐视频，没有推荐结果时删除
+func SetUserRecommend(userId uint, members []redis.Z) {
+	key := RECOMMEND_USER_KEY + strconv.Itoa(int(userId))
+	resetZSet(key, members, time.Hour*RECOMMEND_USER_EXPRIRATION_TIME)
+}
+
+// 按分数从高到低获取用户的推荐视频
+func GetUserRecommend(userId uint, count int64) []uint {
+	return zsetIds(ZRevRange(RECOMMEND_USER_KEY+strconv.Itoa(int(userId)), 0, count-1))
+}
+
+// 保存热门视频
+func SetPopularRecommend(members []redis.Z) {
+	resetZSet(RECOMMEND_POPULAR_KEY, members, 0)
+}
+
+// 按分数从高到低获取热门视频
+func GetPopularRecommend(count int64) []uint {
+	return zsetIds(ZRevRange(RECOMMEND_POPULAR_KEY, 0, count-1))
+}
+
+// 写入临时key后重命名，替换有序集合的全部成员
+func resetZSet(key string, members []redis.Z, expiration time.Duration) {
+	if len(members) == 0 {
+		Del(key)
+		return
+	}
+
+	tmp := key + ":rebuild"
+	pipe := redisClient.Pipeline()
+	pipe.Del(ctx, tmp)
+	pipe.ZAdd(ctx, tmp, members...)
+	if expiration > 0 {
+		pipe.Expire(ctx, tmp, expiration)
+	}
+	pipe.Rename(ctx, tmp, key)
+	pipe.Exec(ctx)
+}
+
+func zsetIds(members []string) []uint {
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		if id, err := strconv.ParseUint(member, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// 保存视频的相关视频，没有相关视频时写入较短过期时间的占位成员
+func SetRelatedVideo(videoId uint, members []redis.Z) {
+	key := RELATED_VIDEO_KEY + convert.UintToString(videoId)
+	if len(members) == 0 {
+		resetZSet(key, []redis.Z{{Member: RELATED_VIDEO_EMPTY_MEMBER}}, time.Minute*RELATED_VIDEO_EMPTY_EXPRIRATION_TIME)
+		return
+	}
+
+	resetZSet(key, members, time.Hour*RELATED_VIDEO_EXPRIRATION_TIME)
+}
+
+// 按分数从高到低获取相关视频，第二个返回值表示是否有缓存(包括没有相关视频的占位成员)
+func GetRelatedVideo(videoId uint, count int64) ([]uint, bool) {
+	members := ZRevRange(RELATED_VIDEO_KEY+convert.UintToString(videoId), 0, count-1)
+	if len(members) == 0 {
+		return nil, false
+	}
+
+	// 占位成员不是数字，会被忽略
+	return zsetIds(members), true
+}
+
+func DelRelatedVideo(videoId uint) {
+	Del(RELATED_VIDEO_KEY + convert.UintToString(videoId))
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"clicli/util/convert"
+	"clicli/util/random"
+	"github.com/go-redis/redis/v9"
+)
+
+// 搜索建议成员格式为 规范化的内容 + 分隔符 + 展示的内容，分数都为0以便按字典序前缀匹配
+const SUGGEST_SEPARATOR = "\x00"
+
+// 增加客户端搜索次数，返回统计窗口内的次数
+func IncrSearchRate(client string) int64 {
+	return IncrWithExpire(SEARCH_RATE_KEY+client, time.Second*SEARCH_RATE_EXPRIRATION_TIME)
+}
+
+// 记录客户端搜索过的词，一段时间内第一次搜索时返回true
+func SetSearchRecord(client, keyword string) bool {
+	return SetNX(SEARCH_RECORD_KEY+client+":"+keyword, 1, time.Hour*SEARCH_RECORD_EXPRIRATION_TIME)
+}
+
+// 标记视频的点赞数或播放量有变化
+func AddSearchStats(videoId uint) {
+	SAdd(SEARCH_STATS_KEY, videoId)
+}
+
+// 取出最多count个有变化的视频
+func PopSearchStats(count int64) []uint {
+	members := SPopN(SEARCH_STATS_KEY, count)
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		if id := convert.StringToUint(member); id != 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// 记录一次搜索
+func IncrSearchCount(keyword string) {
+	ZIncrBy(SEARCH_COUNT_KEY, 1, keyword)
+
+	hourlyKey := SEARCH_HOURLY_KEY + strconv.FormatInt(time.Now().Unix()/3600, 10)
+	ZIncrBy(hourlyKey, 1, keyword)
+	Expire(hourlyKey, time.Hour*SEARCH_HOURLY_EXPRIRATION_TIME)
+}
+
+// 按搜索次数从高到低获取搜索词
+func GetSearchCount(start, stop int64) []redis.Z {
+	return ZRevRangeWithScores(SEARCH_COUNT_KEY, start, stop)
+}
+
+// 搜索词总数
+func GetSearchCountTotal() int64 {
+	return ZCard(SEARCH_COUNT_KEY)
+}
+
+// 只保留搜索次数最多的词
+func TrimSearchCount() {
+	ZRemRangeByRank(SEARCH_COUNT_KEY, 0, -SEARCH_COUNT_LIMIT-1)
+}
+
+/**
+ * 获取热门搜索
+ * 合并最近hours小时的搜索次数，每经过halfLife小时权重减半
+ * 合并结果缓存几分钟，避免每次请求都重新计算
+ */
+func GetTrendingSearch(hours int, halfLife float64, count int64) []redis.Z {
+	if !Exists(SEARCH_TRENDING_KEY) {
+		now := time.Now().Unix() / 3600
+		keys := make([]string, hours)
+		weights := make([]float64, hours)
+		for i := 0; i < hours; i++ {
+			keys[i] = SEARCH_HOURLY_KEY + strconv.FormatInt(now-int64(i), 10)
+			weights[i] = math.Pow(0.5, float64(i)/halfLife)
+		}
+		ZUnionStore(SEARCH_TRENDING_KEY, keys, weights)
+		Expire(SEARCH_TRENDING_KEY, time.Minute*SEARCH_TRENDING_EXPRIRATION_TIME)
+	}
+
+	return ZRevRangeWithScores(SEARCH_TRENDING_KEY, 0, count-1)
+}
+
+// 添加或更新搜索建议
+func SetSearchSuggest(normalized, display string, score float64) {
+	ZAdd(SEARCH_SUGGEST_KEY, 0, normalized+SUGGEST_SEPARATOR+display)
+	ZAdd(SEARCH_SUGGEST_SCORE_KEY, score, display)
+}
+
+// 移除搜索建议
+func DelSearchSuggest(normalized, display string) {
+	ZRem(SEARCH_SUGGEST_KEY, normalized+SUGGEST_SEPARATOR+display)
+	ZRem(SEARCH_SUGGEST_SCORE_KEY, display)
+}
+
+// 获取重建搜索建议的锁，返回锁的标识，其他节点正在重建时返回空字符串
+func LockSearchSuggest() string {
+	token := strconv.FormatInt(time.Now().UnixNano(), 36) + random.GenerateNumberCode(6)
+	if !SetNX(SEARCH_SUGGEST_LOCK_KEY, token, time.Minute*SEARCH_SUGGEST_LOCK_EXPRIRATION_TIME) {
+		return ""
+	}
+	return token
+}
+
+// 释放重建搜索建议的锁
+func UnlockSearchSuggest(token string) {
+	DelIfEqual(SEARCH_SUGGEST_LOCK_KEY, token)
+}
+
+/**
+ * 使用新的数据替换全部搜索建议，先写入临时key再重命名
+ * 临时key使用锁的标识，锁过期后其他节点的重建不会覆盖写入中的数据
+ */
+func ResetSearchSuggest(token string, normalized, display []string, scores []float64) error {
+	if len(normalized) == 0 {
+		Del(SEARCH_SUGGEST_KEY)
+		Del(SEARCH_SUGGEST_SCORE_KEY)
+		return nil
+	}
+
+	suggestKey := SEARCH_SUGGEST_KEY + ":rebuild:" + token
+	scoreKey := SEARCH_SUGGEST_SCORE_KEY + ":rebuild:" + token
+	pipe := redisClient.Pipeline()
+	for i := range normalized {
+		pipe.ZAdd(ctx, suggestKey, redis.Z{Score: 0, Member: normalized[i] + SUGGEST_SEPARATOR + display[i]})
+		pipe.ZAdd(ctx, scoreKey, redis.Z{Score: scores[i], Member: display[i]})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		Del(suggestKey)
+		Del(scoreKey)
+		return err
+	}
+
+	pipe = redisClient.TxPipeline()
+	pipe.Rename(ctx, suggestKey, SEARCH_SUGGEST_KEY)
+	pipe.Rename(ctx, scoreKey, SEARCH_SUGGEST_SCORE_KEY)
+	if _, err := pipe.Exec(ctx); err != nil {
+		Del(suggestKey)
+		Del(scoreKey)
+		return err
+	}
+	return nil
+}
+
+// 获取前缀匹配的搜索建议，最多返回limit个
+func GetSearchSuggest(prefix string, limit int64) []redis.Z {
+	members := ZRangeByLex(SEARCH_SUGGEST_KEY, "["+prefix, "["+prefix+"\xff", limit)
+	if len(members) == 0 {
+		return make([]redis.Z, 0)
+	}
+
+	pipe := redisClient.Pipeline()
+	cmds := make([]*redis.FloatCmd, len(members))
+	for i, member := range members {
+		_, display, _ := strings.Cut(member, SUGGEST_SEPARATOR)
+		members[i] = display
+		cmds[i] = pipe.ZScore(ctx, SEARCH_SUGGEST_SCORE_KEY, display)
+	}
+	pipe.Exec(ctx)
+
+	suggestions := make([]redis.Z, len(members))
+	for i, display := range members {
+		suggestions[i] = redis.Z{Member: display, Score: cmds[i].Val()}
+	}
+	return suggestions
+}
+
+// 添加屏蔽词
+func AddSearchBlock(word string) {
+	SAdd(SEARCH_BLOCK_KEY, word)
+}
+
+// 删除屏蔽词
+func DelSearchBlock(word string) {
+	SRem(SEARCH_BLOCK_KEY, word)
+}
+
+// 获取所有屏蔽词
+func GetSearchBlock() []string {
+	return SMembers(SEARCH_BLOCK_KEY)
+}
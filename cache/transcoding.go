@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"encoding/json"
+	"time"
+
+	"clicli/domain/vo"
+	"clicli/util/convert"
+	"go.uber.org/zap"
+)
+
+func GetTranscodingProgress(resourceId uint) (progress vo.TranscodingProgressVO) {
+	jsonStr := Get(TRANSCODING_PROGRESS_KEY + convert.UintToString(resourceId))
+	if jsonStr == "" {
+		return
+	}
+
+	// 反序列化
+	if err := json.Unmarshal([]byte(jsonStr), &progress); err != nil {
+		zap.L().Error("转码进度反序列化失败: " + err.Error())
+	}
+	return
+}
+
+func SetTranscodingProgress(progress vo.TranscodingProgressVO) {
+	//先序列化
+	pb, err := json.Marshal(progress)
+	if err != nil {
+		zap.L().Error("转码进度序列化失败: " + err.Error())
+		return
+	}
+	Set(TRANSCODING_PROGRESS_KEY+convert.UintToString(progress.Rid), pb,
+		time.Hour*TRANSCODING_PROGRESS_EXPRIRATION_TIME)
+}
+
+func DelTranscodingProgress(resourceId uint) {
+	Del(TRANSCODING_PROGRESS_KEY + convert.UintToString(resourceId))
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"encoding/json"
+	"time"
+
+	"clicli/domain/model"
+	"go.uber.org/zap"
+)
+
+func GetUploadSession(id string) (session model.UploadSession) {
+	jsonStr := Get(UPLOAD_SESSION_KEY + id)
+	if jsonStr == "" {
+		return
+	}
+
+	// 反序列化
+	if err := json.Unmarshal([]byte(jsonStr), &session); err != nil {
+		zap.L().Error("上传会话反序列化失败: " + err.Error())
+	}
+	return
+}
+
+func SetUploadSession(session model.UploadSession) {
+	//先序列化
+	sb, err := json.Marshal(session)
+	if err != nil {
+		zap.L().Error("上传会话序列化失败: " + err.Error())
+		return
+	}
+	Set(UPLOAD_SESSION_KEY+session.ID, sb, time.Hour*UPLOAD_SESSION_EXPRIRATION_TIME)
+}
+
+// 刷新上传会话过期时间
+func RefreshUploadSession(id string) {
+	Expire(UPLOAD_SESSION_KEY+id, time.Hour*UPLOAD_SESSION_EXPRIRATION_TIME)
+}
+
+func IsUploadSessionExists(id string) bool {
+	return Exists(UPLOAD_SESSION_KEY + id)
+}
+
+func DelUploadSession(id string) {
+	Del(UPLOAD_SESSION_KEY + id)
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"strconv"
+	"time"
+)
+
+// 刷新节点存活状态
+func RefreshWsNode(node string) {
+	Set(WS_NODE_KEY+node, 1, time.Second*WS_NODE_EXPRIRATION_TIME)
+}
+
+// 设置节点在房间中的在线人数
+func SetWsRoomOnline(room, node string, count int) {
+	if count > 0 {
+		HSet(WS_ROOM_ONLINE_KEY+room, node, count)
+	} else {
+		HDel(WS_ROOM_ONLINE_KEY+room, node)
+	}
+}
+
+// 获取房间在所有节点的在线人数，同时清理已下线节点的数据
+func GetWsRoomOnline(room string) int {
+	total := 0
+	for node, value := range HGetAll(WS_ROOM_ONLINE_KEY + room) {
+		if !Exists(WS_NODE_KEY + node) {
+			HDel(WS_ROOM_ONLINE_KEY+room, node)
+			continue
+		}
+		count, _ := strconv.Atoi(value)
+		total += count
+	}
+	return total
+}
@@ -0,0 +1,11 @@
+package common
+
+// 直播录像状态
+const (
+	// 录制中
+	LIVE_RECORDING = 0
+	// 已转为视频
+	LIVE_RECORD_ARCHIVED = 100
+	// 录像处理失败
+	LIVE_RECORD_FAILED = 200
+)
@@ -0,0 +1,31 @@
+package common
+
+// 转码任务状态
+const (
+	// 等待执行
+	TASK_PENDING = 0
+	// 执行中
+	TASK_RUNNING = 100
+	// 执行完成
+	TASK_FINISHED = 200
+	// 执行失败(超过最大重试次数)
+	TASK_FAILED = 300
+)
+
+// 转码任务步骤(记录已完成的步骤，重启或重试时从下一步继续)
+const (
+	// 任务已创建
+	STEP_CREATED = 0
+	// 标准化视频编码
+	STEP_NORMALIZE_VIDEO = 1
+	// 提取音频
+	STEP_EXTRACT_AUDIO = 2
+	// 压制不同分辨率
+	STEP_PRESSING_VIDEO = 3
+	// 生成dash分片
+	STEP_GENERATE_DASH = 4
+	// 生成预览图和候选封面
+	STEP_GENERATE_STORYBOARD = 5
+	// 上传OSS
+	STEP_UPLOAD_OSS = 6
+)
@@ -39,6 +39,8 @@ func InitTables() {
 	mysqlClient.AutoMigrate(&model.Partition{})
 	mysqlClient.AutoMigrate(&model.Video{})
 	mysqlClient.AutoMigrate(&model.Resource{})
+	mysqlClient.AutoMigrate(&model.Subtitle{})
+	mysqlClient.AutoMigrate(&model.TranscodingTask{})
 	mysqlClient.AutoMigrate(&model.Collection{})
 	mysqlClient.AutoMigrate(&model.Follow{})
 	mysqlClient.AutoMigrate(&model.Announce{})
@@ -48,5 +50,66 @@ func InitTables() {
 	mysqlClient.AutoMigrate(&model.ReplyMessage{})
 	mysqlClient.AutoMigrate(&model.History{})
 	mysqlClient.AutoMigrate(&model.Danmaku{})
+	mysqlClient.AutoMigrate(&model.DanmakuReport{})
+	mysqlClient.AutoMigrate(&model.FilterWord{})
 	mysqlClient.AutoMigrate(&model.Carousel{})
+	mysqlClient.AutoMigrate(&model.LiveRoom{})
+	mysqlClient.AutoMigrate(&model.LiveRecord{})
+	mysqlClient.AutoMigrate(&model.LiveDanmaku{})
+
+	// 旧版直播数据需要手动开启迁移，迁移前查询视频时排除这些记录
+	if CheckLegacyLive(mysqlClient) {
+		if viper.GetBool("mysql.migrate_legacy_live") {
+			MigrateLegacyLive(mysqlClient)
+		} else {
+			zap.L().Info("视频表中存在旧版直播记录，开启mysql.migrate_legacy_live后迁移")
+		}
+	}
+}
+
+// 旧版直播记录的查询条件
+const LEGACY_LIVE_CONDITION = "video = 0 and flv <> ''"
+
+// 视频表中是否还有未迁移的旧版直播记录
+var legacyLive bool
+
+// 检查视频表中是否有旧版直播记录
+func CheckLegacyLive(db *gorm.DB) bool {
+	legacyLive = false
+	migrator := db.Migrator()
+	if migrator.HasColumn(&model.Video{}, "video") && migrator.HasColumn(&model.Video{}, "flv") {
+		var count int64
+		db.Model(&model.Video{}).Where(LEGACY_LIVE_CONDITION).Count(&count)
+		legacyLive = count > 0
+	}
+
+	return legacyLive
+}
+
+/**
+ * 排除未迁移的旧版直播记录，迁移完成后不再添加条件
+ * 新上传的视频没有video和flv字段的值(NULL)，需要当作普通视频
+ */
+func ExcludeLegacyLive(db *gorm.DB) *gorm.DB {
+	if !legacyLive {
+		return db
+	}
+
+	return db.Where("not (coalesce(video, 1) = 0 and coalesce(flv, '') <> '')")
+}
+
+/**
+ * 旧版本的直播间是视频表中video字段为0且提交过密钥(flv字段不为空)的记录，普通视频的video字段为1
+ * 改用直播间表后删除(软删除)这些记录，video和flv字段不再使用，确认数据无误后可以手动删除
+ */
+func MigrateLegacyLive(db *gorm.DB) (int64, error) {
+	result := db.Where(LEGACY_LIVE_CONDITION).Delete(&model.Video{})
+	if result.Error != nil {
+		zap.L().Error("删除旧版直播记录失败" + result.Error.Error())
+		return 0, result.Error
+	}
+
+	legacyLive = false
+	zap.L().Info(fmt.Sprintf("旧版直播数据迁移完成，删除%d条记录", result.RowsAffected))
+	return result.RowsAffected, nil
 }
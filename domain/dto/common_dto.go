@@ -7,19 +7,10 @@ type EmailDTO struct {
 	Email string
 }
 
-type LikeDTO struct {
-	ID uint
-}
-
 type IdDTO struct {
 	ID uint
 }
 
-type IDflv struct {
-	ID     uint
-	Flvkey string
-}
-
 type ObjectIdDTO struct {
 	ID primitive.ObjectID
 }
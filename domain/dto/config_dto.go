@@ -10,9 +10,8 @@ type EmailConfigDTO struct {
 }
 
 type StorageConfigDTO struct {
-	MaxImgSize         int
-	MaxVideoSize       int
-	VideoAdaptationIos bool
+	MaxImgSize   int
+	MaxVideoSize int
 
 	Type      string
 	KeyID     string
@@ -23,9 +22,31 @@ type StorageConfigDTO struct {
 	Region    string
 	Domain    string
 	Private   bool
+	PathStyle bool
+}
+
+type GcConfigDTO struct {
+	DryRun      bool
+	GracePeriod int
 }
 
 type OtherConfigDTO struct {
 	AllowOrigin string
 	Prefix      string
 }
+
+type TranscodingConfigDTO struct {
+	Profiles        []EncodingProfileDTO
+	KeepAspectRatio bool
+	Portrait        bool
+}
+
+type EncodingProfileDTO struct {
+	Quality   int
+	Bitrate   int
+	Crf       int
+	Codec     string
+	Preset    string
+	FpsPolicy string
+	Fps       float64
+}
@@ -11,6 +11,29 @@ type DanmakuDTO struct {
 	Text  string
 }
 
+// 举报弹幕
+type DanmakuReportDTO struct {
+	ID     uint
+	Reason string
+}
+
+// 批量删除弹幕
+type DanmakuIdsDTO struct {
+	IDs []uint
+}
+
+// 禁止用户发送弹幕
+type DanmakuMuteDTO struct {
+	Uid   uint
+	Hours int // 禁言时长(小时)，为0时解除禁言
+}
+
+// 添加违禁词
+type FilterWordDTO struct {
+	Word  string
+	Regex bool // 是否为正则表达式
+}
+
 /**
  * DanmakuDTO结构体转化为Danmaku结构体
  * param: danmakuDTO DanmakuDTO
@@ -28,3 +51,31 @@ func DanmakuDtoToDanmaku(danmakuDTO DanmakuDTO, userId uint) model.Danmaku {
 		Text:  danmakuDTO.Text,
 	}
 }
+
+/**
+ * DanmakuReportDTO结构体转化为DanmakuReport结构体
+ * param: reportDTO DanmakuReportDTO
+ * param: videoId 视频ID
+ * param: userId 举报用户ID
+ * return: DanmakuReport结构体
+ */
+func DanmakuReportDtoToDanmakuReport(reportDTO DanmakuReportDTO, videoId, userId uint) model.DanmakuReport {
+	return model.DanmakuReport{
+		Did:    reportDTO.ID,
+		Vid:    videoId,
+		Uid:    userId,
+		Reason: reportDTO.Reason,
+	}
+}
+
+/**
+ * FilterWordDTO结构体转化为FilterWord结构体
+ * param: wordDTO FilterWordDTO
+ * return: FilterWord结构体
+ */
+func FilterWordDtoToFilterWord(wordDTO FilterWordDTO) model.FilterWord {
+	return model.FilterWord{
+		Word:  wordDTO.Word,
+		Regex: wordDTO.Regex,
+	}
+}
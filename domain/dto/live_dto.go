@@ -0,0 +1,47 @@
+package dto
+
+import "clicli/domain/model"
+
+// 修改直播间信息
+type ModifyLiveRoomDTO struct {
+	Title string
+	Cover string
+}
+
+// SRS回调参数
+type SrsCallbackDTO struct {
+	Action   string `json:"action"`
+	ClientId string `json:"client_id"`
+	Ip       string `json:"ip"`
+	Vhost    string `json:"vhost"`
+	App      string `json:"app"`
+	Stream   string `json:"stream"`
+	Param    string `json:"param"`
+	// on_dvr回调的工作目录和录像文件
+	Cwd  string `json:"cwd"`
+	File string `json:"file"`
+}
+
+// 发送直播弹幕
+type LiveDanmakuDTO struct {
+	Rid   uint // 直播间ID
+	Type  int  //类型0滚动;1顶部;2底部
+	Color string
+	Text  string
+}
+
+/**
+ * LiveDanmakuDTO结构体转化为LiveDanmaku结构体
+ * param: danmakuDTO LiveDanmakuDTO
+ * param: userID 用户ID
+ * return: LiveDanmaku结构体
+ */
+func LiveDanmakuDtoToLiveDanmaku(danmakuDTO LiveDanmakuDTO, userId uint) model.LiveDanmaku {
+	return model.LiveDanmaku{
+		RoomId: danmakuDTO.Rid,
+		Uid:    userId,
+		Type:   danmakuDTO.Type,
+		Color:  danmakuDTO.Color,
+		Text:   danmakuDTO.Text,
+	}
+}
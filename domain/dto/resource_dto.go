@@ -19,11 +19,12 @@ type ModifyResourceTitleDTO struct {
  * param: resDTO 不同分辨率DTO结构体
  * return: Resource结构体
  */
-func ResourceDtoToResource(vid, uid uint, quality int, duration float64, url, originalUrl string) model.Resource {
+func ResourceDtoToResource(vid, uid uint, quality int, duration float64, url, hlsUrl, originalUrl string) model.Resource {
 	return model.Resource{
 		Vid:         vid,
 		Uid:         uid,
 		Url:         url,
+		HlsUrl:      hlsUrl,
 		OriginalUrl: originalUrl,
 		Duration:    duration,
 		Status:      common.VIDEO_PROCESSING,
@@ -0,0 +1,8 @@
+package dto
+
+// 修改字幕
+type ModifySubtitleDTO struct {
+	ID    uint
+	Lang  string // 语言代码
+	Label string // 显示名称
+}
@@ -0,0 +1,13 @@
+package dto
+
+// 初始化分片上传
+type InitChunkUploadDTO struct {
+	Vid      uint
+	Filename string
+	Size     int64
+}
+
+// 完成分片上传
+type CompleteChunkUploadDTO struct {
+	ID string
+}
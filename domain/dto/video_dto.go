@@ -11,7 +11,6 @@ type UploadVideoDTO struct {
 	Desc      string
 	Copyright bool
 	Partition uint //分区ID
-	Video     int
 }
 
 // 修改视频信息
@@ -29,6 +28,23 @@ type ReviewDTO struct {
 	Status int
 }
 
+// 搜索视频
+type SearchVideoDTO struct {
+	Keywords  string
+	Partition uint   // 分区ID，可以是上级分区
+	Duration  int    // 时长区间 0:全部 1:10分钟以下 2:10-30分钟 3:30-60分钟 4:60分钟以上
+	Copyright int    // 0:全部 1:原创 2:转载
+	BeginTime string // 发布日期范围，格式2006-01-02
+	EndTime   string
+	Sort      string // 为空时综合排序 clicks:播放量 likes:点赞数 newest:最新发布
+	Page      int
+	PageSize  int
+}
+
+type SearchBlockDTO struct {
+	Word string
+}
+
 /**
  * 上传视频DTO结构体转化为Video结构体
  * param: userId 用户Id
@@ -44,6 +60,5 @@ func UploadVideoDtoToVideo(userId uint, uploadVideoDTO UploadVideoDTO) model.Vid
 		Copyright:   uploadVideoDTO.Copyright,
 		PartitionId: uploadVideoDTO.Partition,
 		Status:      common.CREATED_VIDEO,
-		Video:       uploadVideoDTO.Video,
 	}
 }
@@ -0,0 +1,15 @@
+package model
+
+import "gorm.io/gorm"
+
+type DanmakuReport struct {
+	gorm.Model
+	Did    uint   `gorm:"comment:'弹幕ID';not null;uniqueIndex:idx_danmaku_report"`
+	Vid    uint   `gorm:"comment:'视频ID';not null"`
+	Uid    uint   `gorm:"comment:'举报用户ID';not null;uniqueIndex:idx_danmaku_report"`
+	Reason string `gorm:"type:varchar(100);comment:'举报原因'"`
+}
+
+func (table *DanmakuReport) TableName() string {
+	return "danmaku_report"
+}
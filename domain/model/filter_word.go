@@ -0,0 +1,13 @@
+package model
+
+import "gorm.io/gorm"
+
+type FilterWord struct {
+	gorm.Model
+	Word  string `gorm:"type:varchar(100);comment:'违禁词或正则表达式';not null"`
+	Regex bool   `gorm:"comment:'是否为正则表达式';default:false"`
+}
+
+func (table *FilterWord) TableName() string {
+	return "filter_word"
+}
@@ -0,0 +1,18 @@
+package model
+
+import "gorm.io/gorm"
+
+type LiveDanmaku struct {
+	gorm.Model
+	RoomId   uint   `gorm:"comment:'直播间ID';not null;index"`
+	RecordId uint   `gorm:"comment:'录像ID';default:0;index"`
+	Time     uint   `gorm:"comment:'相对开播的时间';not null"`
+	Type     int    `gorm:"comment:'类型0滚动;1顶部;2底部';default:0"`
+	Color    string `gorm:"type:varchar(10);comment:'颜色';default:'#fff'"`
+	Text     string `gorm:"type:varchar(100);comment:'内容';not null"`
+	Uid      uint   `gorm:"comment:'用户ID';not null"`
+}
+
+func (table *LiveDanmaku) TableName() string {
+	return "live_danmaku"
+}
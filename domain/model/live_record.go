@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LiveRecord struct {
+	gorm.Model
+	RoomId    uint      `gorm:"comment:'直播间ID';not null;index"`
+	Uid       uint      `gorm:"comment:'用户ID';not null"`
+	Title     string    `gorm:"type:varchar(50);comment:'直播标题'"`
+	ClientId  string    `gorm:"type:varchar(64);comment:'推流客户端ID'"`
+	Dir       string    `gorm:"type:varchar(64);comment:'录像文件夹'"`
+	StartedAt time.Time `gorm:"comment:'开播时间'"`
+	Vid       uint      `gorm:"comment:'转存的视频ID';default:0"`
+	Status    int       `gorm:"comment:'录像状态';default:0"`
+}
+
+func (table *LiveRecord) TableName() string {
+	return "live_record"
+}
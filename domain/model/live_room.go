@@ -0,0 +1,18 @@
+package model
+
+import "gorm.io/gorm"
+
+type LiveRoom struct {
+	gorm.Model
+	Uid        uint   `gorm:"comment:'用户ID';not null;uniqueIndex"`
+	Title      string `gorm:"type:varchar(50);comment:'直播间标题'"`
+	Cover      string `gorm:"type:varchar(255);comment:'直播间封面'"`
+	StreamName string `gorm:"type:varchar(64);comment:'推流名称';not null;uniqueIndex"`
+	StreamKey  string `gorm:"type:varchar(64);comment:'推流密钥，为空表示已吊销'"`
+
+	Author User `gorm:"-"`
+}
+
+func (table *LiveRoom) TableName() string {
+	return "live_room"
+}
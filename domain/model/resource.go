@@ -8,10 +8,15 @@ type Resource struct {
 	Uid         uint    `gorm:"comment:'所属用户';index"`
 	Title       string  `gorm:"type:varchar(50);comment:'分P使用的标题'"`
 	Url         string  `gorm:"type:varchar(255);comment:'视频链接'"`
+	HlsUrl      string  `gorm:"type:varchar(255);comment:'HLS播放列表链接'"`
 	OriginalUrl string  `gorm:"type:varchar(255);comment:'原始mp4链接'"`
+	Storyboard  string  `gorm:"type:varchar(255);comment:'预览图WebVTT链接'"`
+	Covers      string  `gorm:"type:varchar(1000);comment:'候选封面链接，逗号分隔'"`
 	Duration    float64 `gorm:"comment:'视频时长';default:0"`
 	Status      int     `gorm:"comment:'审核状态';not null;index"`
 	Quality     int     `gorm:"comment:'视频最大质量';"`
+
+	Subtitles []Subtitle `gorm:"-"`
 }
 
 func (table *Resource) TableName() string {
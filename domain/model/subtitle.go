@@ -0,0 +1,16 @@
+package model
+
+import "gorm.io/gorm"
+
+type Subtitle struct {
+	gorm.Model
+	Rid   uint   `gorm:"comment:'所属资源';index"`
+	Uid   uint   `gorm:"comment:'所属用户'"`
+	Lang  string `gorm:"type:varchar(20);comment:'语言代码'"`
+	Label string `gorm:"type:varchar(50);comment:'显示名称'"`
+	Url   string `gorm:"type:varchar(255);comment:'WebVTT字幕链接'"`
+}
+
+func (table *Subtitle) TableName() string {
+	return "subtitle"
+}
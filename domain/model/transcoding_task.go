@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TranscodingTask struct {
+	gorm.Model
+	Rid       uint      `gorm:"comment:'资源ID';not null;index"`
+	Dir       string    `gorm:"type:varchar(50);comment:'视频文件夹';not null"`
+	Quality   int       `gorm:"comment:'视频最大质量'"`
+	Step      int       `gorm:"comment:'已完成的步骤';default:0"`
+	Status    int       `gorm:"comment:'任务状态';default:0;index"`
+	Retry     int       `gorm:"comment:'重试次数';default:0"`
+	NextRunAt time.Time `gorm:"comment:'下次执行时间'"`
+	Error     string    `gorm:"type:varchar(255);comment:'最后一次错误信息'"`
+	Encoding  string    `gorm:"type:text;comment:'创建任务时的编码阶梯(JSON)'"`
+}
+
+func (table *TranscodingTask) TableName() string {
+	return "transcoding_task"
+}
@@ -0,0 +1,12 @@
+package model
+
+// 分片上传会话(保存在redis中)
+type UploadSession struct {
+	ID         string // 会话ID，同时作为视频文件夹名
+	Vid        uint   // 视频ID
+	Uid        uint   // 用户ID
+	Filename   string // 原始文件名
+	Size       int64  // 文件总大小(字节)
+	ChunkSize  int64  // 分片大小(字节)
+	ChunkCount int    // 分片数量
+}
@@ -12,8 +12,6 @@ type Video struct {
 	Clicks      int64  `gorm:"comment:'点击量';default:0"`
 	Status      int    `gorm:"comment:'审核状态';not null"`
 	PartitionId uint   `gorm:"comment:'分区ID';deult:0"`
-	Video       int    `gorm:"comment:'直播';deult:0"`
-	Flv         string `gorm:"comment:'密钥';deult:0"`
 
 	Author User `gorm:"-"`
 }
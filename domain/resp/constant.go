@@ -35,23 +35,37 @@ var (
 
 	FileCheckError = R{httpStatus: http.StatusOK, code: 4020, msg: "文件不符合要求"}
 
+	FileContentError    = R{httpStatus: http.StatusOK, code: 4021, msg: "文件内容与类型不符"}
+	ImageDecodeError    = R{httpStatus: http.StatusOK, code: 4022, msg: "图片文件无法解析"}
+	ImageDimensionError = R{httpStatus: http.StatusOK, code: 4023, msg: "图片尺寸超出限制"}
+	VideoDecodeError    = R{httpStatus: http.StatusOK, code: 4024, msg: "视频文件无法解析"}
+	VideoDimensionError = R{httpStatus: http.StatusOK, code: 4025, msg: "视频分辨率超出限制"}
+
 	FileUploadError = R{httpStatus: http.StatusOK, code: 4030, msg: "文件上传失败"}
 
 	PartitionError          = R{httpStatus: http.StatusOK, code: 4040, msg: "分区不存在"}
 	ParentPartitionError    = R{httpStatus: http.StatusOK, code: 4040, msg: "所属分区不存在"}
 	UserNotExistError       = R{httpStatus: http.StatusOK, code: 4040, msg: "用户不存在"}
 	VideoNotExistError      = R{httpStatus: http.StatusOK, code: 4040, msg: "视频不存在"}
-	LikeNotExistError       = R{httpStatus: http.StatusOK, code: 4040, msg: "直播不存在"}
+	LiveNotExistError       = R{httpStatus: http.StatusOK, code: 4040, msg: "直播不存在"}
 	ResourceNotExistError   = R{httpStatus: http.StatusOK, code: 4040, msg: "资源不存在"}
 	CollectionNotExistError = R{httpStatus: http.StatusOK, code: 4040, msg: "收藏夹不存在"}
 	CommentNotExistError    = R{httpStatus: http.StatusOK, code: 4040, msg: "评论或回复不存在"}
 	KeyNotExistError        = R{httpStatus: http.StatusOK, code: 4040, msg: "密钥为空"}
 
+	UploadSessionNotExistError = R{httpStatus: http.StatusOK, code: 4040, msg: "上传会话不存在或已过期"}
+	DanmakuNotExistError       = R{httpStatus: http.StatusOK, code: 4040, msg: "弹幕不存在"}
+	SubtitleNotExistError      = R{httpStatus: http.StatusOK, code: 4040, msg: "字幕不存在"}
+
 	TooManyRequestsError = R{httpStatus: http.StatusOK, code: 4050, msg: "请求数量过多"}
 
 	FollowYourselfError = R{httpStatus: http.StatusOK, code: 4060, msg: "不能关注自己"}
 
+	ContentFilterError = R{httpStatus: http.StatusOK, code: 4070, msg: "内容包含违禁词"}
+	UserMutedError     = R{httpStatus: http.StatusOK, code: 4071, msg: "已被禁止发送弹幕"}
+
 	// 50** 服务器相关错误
+	SearchNotReadyError = R{httpStatus: http.StatusOK, code: 5000, msg: "搜索服务正在启动"}
 
 	// 60** 用户相关错误
 	NameExistError  = R{httpStatus: http.StatusOK, code: 6000, msg: "用户名已存在"}
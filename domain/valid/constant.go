@@ -16,11 +16,32 @@ const (
 	FILE_TYPE_ERROR = "文件类型不符合要求"
 	FILE_SIZE_ERROR = "文件大小不符合要求"
 
+	// 文件内容校验
+	FILE_CONTENT_ERROR    = "文件内容与类型不符"
+	IMAGE_DECODE_ERROR    = "图片文件无法解析"
+	IMAGE_DIMENSION_ERROR = "图片尺寸超出限制"
+	VIDEO_DECODE_ERROR    = "视频文件无法解析"
+	VIDEO_DIMENSION_ERROR = "视频分辨率超出限制"
+
+	// 分片上传
+	CHUNK_INDEX_ERROR = "分片序号无效"
+	CHUNK_SIZE_ERROR  = "分片大小不符合要求"
+	CHUNK_HASH_ERROR  = "分片校验失败"
+	CHUNK_LOST_ERROR  = "分片不完整"
+
 	// 用户
 	ROLE_ERROR = "无效的角色"
 
 	// 视频
 	REVIEW_STATUS_ERROR = "无效的视频状态"
+	SEARCH_PARAM_ERROR  = "搜索条件有误"
+	SEARCH_BLOCK_ERROR  = "屏蔽词不符合长度要求"
+
+	// 转码
+	ENCODING_PROFILE_ERROR = "编码配置不符合要求"
+
+	// 存储清理
+	GC_GRACE_PERIOD_ERROR = "宽限期必须大于0"
 
 	// 评论校验
 	COMMENT_CONTENT_ERROR = "评论或回复内容不能为空"
@@ -36,5 +57,18 @@ const (
 	SEND_YOURSELF_ERROR   = "不能发送给自己"
 
 	// 弹幕
-	DANMAKU_TEXT_ERROR = "弹幕内容不能为空"
+	DANMAKU_TEXT_ERROR   = "弹幕内容不能为空"
+	DANMAKU_REPORT_ERROR = "举报原因不符合长度要求"
+	DANMAKU_IDS_ERROR    = "一次最多删除100条弹幕"
+
+	// 违禁词
+	FILTER_WORD_ERROR  = "违禁词不符合长度要求"
+	FILTER_REGEX_ERROR = "正则表达式有误"
+
+	// 字幕
+	SUBTITLE_LANG_ERROR   = "无效的字幕语言"
+	SUBTITLE_LABEL_ERROR  = "字幕名称不符合长度要求"
+	SUBTITLE_FORMAT_ERROR = "字幕文件格式有误"
+	SUBTITLE_COUNT_ERROR  = "字幕数量超出限制"
+	SUBTITLE_EXIST_ERROR  = "该语言的字幕已存在"
 )
@@ -1,5 +1,21 @@
 package valid
 
+import "unicode/utf8"
+
 func DanmakuText(text string) bool {
 	return len(text) > 0
 }
+
+func DanmakuReportReason(reason string) bool {
+	return utf8.RuneCountInString(reason) <= 100
+}
+
+// 单次批量删除的弹幕数量
+func DanmakuIds(ids []uint) bool {
+	return len(ids) > 0 && len(ids) <= 100
+}
+
+func FilterWord(word string) bool {
+	length := utf8.RuneCountInString(word)
+	return length > 0 && length <= 100
+}
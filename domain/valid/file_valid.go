@@ -8,9 +8,9 @@ import (
 )
 
 func FileType(suffix string, isImg bool) bool {
-	pattern := `.(png|jpeg|jpg)`
+	pattern := `(?i)^\.(png|jpeg|jpg)$`
 	if !isImg {
-		pattern = `.(mp4)`
+		pattern = `(?i)^\.(mp4|m4v|mov|mkv|webm)$`
 	}
 	reg := regexp.MustCompile(pattern)
 	return reg.MatchString(suffix)
@@ -28,3 +28,14 @@ func FileSize(contentLength string, targetSize int64) bool {
 	}
 	return true
 }
+
+// 校验实际字节数，targetSize 单位为 MB
+func FileBytes(size int64, targetSize int64) bool {
+	return size > 0 && size <= targetSize*1024*1024
+}
+
+// 分片sha256校验值
+func ChunkHash(hash string) bool {
+	reg := regexp.MustCompile(`^[0-9a-f]{64}$`)
+	return reg.MatchString(hash)
+}
@@ -0,0 +1,44 @@
+package valid
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// 文件头读取长度
+const SNIFF_LENGTH = 512
+
+// 根据文件头判断图片类型，返回对应的文件后缀
+func ImageContent(head []byte) (string, bool) {
+	switch http.DetectContentType(head) {
+	case "image/jpeg":
+		return ".jpg", true
+	case "image/png":
+		return ".png", true
+	}
+	return "", false
+}
+
+// 根据文件头判断是否为视频容器(mp4/mov/mkv/webm)
+func VideoContent(head []byte) bool {
+	if len(head) < 8 {
+		return false
+	}
+
+	// mkv 和 webm 使用 EBML 头
+	if bytes.HasPrefix(head, []byte{0x1A, 0x45, 0xDF, 0xA3}) {
+		return true
+	}
+
+	// mp4、m4v 和 mov 的第一个box
+	switch string(head[4:8]) {
+	case "ftyp", "moov", "mdat", "wide", "free", "skip":
+		return true
+	}
+	return false
+}
+
+// 图片或视频尺寸是否超出限制
+func MediaDimension(width, height, maxDimension int) bool {
+	return width > 0 && height > 0 && width <= maxDimension && height <= maxDimension
+}
@@ -0,0 +1,23 @@
+package valid
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// 字幕文件后缀
+func SubtitleType(suffix string) bool {
+	reg := regexp.MustCompile(`(?i)^\.(srt|vtt)$`)
+	return reg.MatchString(suffix)
+}
+
+// BCP 47语言代码，如zh、zh-CN、en-US
+func SubtitleLang(lang string) bool {
+	reg := regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8}){0,2}$`)
+	return len(lang) <= 20 && reg.MatchString(lang)
+}
+
+func SubtitleLabel(label string) bool {
+	length := utf8.RuneCountInString(label)
+	return length > 0 && length <= 20
+}
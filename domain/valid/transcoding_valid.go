@@ -0,0 +1,26 @@
+package valid
+
+import "clicli/domain/dto"
+
+func EncodingProfile(profile dto.EncodingProfileDTO) bool {
+	codecs := map[string]bool{"libx264": true, "libx265": true}
+	presets := map[string]bool{
+		"ultrafast": true, "superfast": true, "veryfast": true, "faster": true, "fast": true,
+		"medium": true, "slow": true, "slower": true, "veryslow": true,
+	}
+	fpsPolicies := map[string]bool{"source": true, "limit": true, "fixed": true}
+
+	if profile.Quality < 144 || profile.Quality > 4320 || profile.Quality%2 != 0 {
+		return false
+	}
+
+	if profile.Bitrate <= 0 || profile.Crf < 0 || profile.Crf > 51 {
+		return false
+	}
+
+	if !codecs[profile.Codec] || !presets[profile.Preset] || !fpsPolicies[profile.FpsPolicy] {
+		return false
+	}
+
+	return profile.FpsPolicy == "source" || (profile.Fps > 0 && profile.Fps <= 120)
+}
@@ -1,5 +1,11 @@
 package valid
 
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
 func ReviewStatus(role int) bool {
 	roles := map[int]string{
 		0:    "AUDIT_APPROVED",
@@ -13,3 +19,37 @@ func ReviewStatus(role int) bool {
 
 	return true
 }
+
+// 搜索时长区间
+func SearchDuration(duration int) bool {
+	return duration >= 0 && duration <= 4
+}
+
+// 搜索原创或转载
+func SearchCopyright(copyright int) bool {
+	return copyright >= 0 && copyright <= 2
+}
+
+// 搜索排序方式
+func SearchSort(sort string) bool {
+	switch sort {
+	case "", "clicks", "likes", "newest":
+		return true
+	}
+	return false
+}
+
+// 搜索日期，可以为空
+func SearchDate(date string) bool {
+	if date == "" {
+		return true
+	}
+	_, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	return err == nil
+}
+
+// 搜索建议屏蔽词
+func SearchBlockWord(word string) bool {
+	length := utf8.RuneCountInString(strings.TrimSpace(word))
+	return length > 0 && length <= 30
+}
@@ -1,7 +1,10 @@
 package vo
 
 import (
+	"strings"
+
 	"clicli/domain/model"
+	"clicli/search"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -22,6 +25,38 @@ type ReplyVo struct {
 	At        []uint             `json:"at"`
 }
 
+// 搜索评论和回复
+type SearchCommentVO struct {
+	// 评论或回复ID
+	ID string `json:"id"`
+	// 所属评论ID，评论时与ID相同
+	CommentID string     `json:"comment_id"`
+	Vid       uint       `json:"vid"`
+	Content   string     `json:"content"`
+	Author    BaseUserVO `json:"author"`
+	CreatedAt int64      `json:"created_at"`
+	// 高亮的内容
+	Highlight string `json:"highlight"`
+}
+
+func ToSearchCommentVO(hit search.Hit, author model.User) SearchCommentVO {
+	commentId, replyId, isReply := strings.Cut(hit.ID, ":")
+	id := commentId
+	if isReply {
+		id = replyId
+	}
+
+	return SearchCommentVO{
+		ID:        id,
+		CommentID: commentId,
+		Vid:       uint(hit.Attrs["vid"]),
+		Content:   hit.Fields["content"],
+		Author:    ToBaseUserVO(author),
+		CreatedAt: int64(hit.Attrs["created_at"]),
+		Highlight: hit.Highlights["content"],
+	}
+}
+
 func ToCommentVO(comments []model.Comment) []CommentVo {
 	length := len(comments)
 	newComments := make([]CommentVo, length)
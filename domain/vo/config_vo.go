@@ -1,5 +1,7 @@
 package vo
 
+import "clicli/util/transcoding"
+
 type EmailConfigVO struct {
 	// Debug     bool   `json:"debug"`
 	User      string `json:"user"`
@@ -10,9 +12,8 @@ type EmailConfigVO struct {
 }
 
 type StorageConfigVO struct {
-	MaxImgSize         int  `json:"max_img_size"`
-	MaxVideoSize       int  `json:"max_video_size"`
-	VideoAdaptationIos bool `json:"video_adaptation_ios"`
+	MaxImgSize   int `json:"max_img_size"`
+	MaxVideoSize int `json:"max_video_size"`
 
 	Type      string `json:"type"`
 	KeyID     string `json:"key_id"`
@@ -23,9 +24,51 @@ type StorageConfigVO struct {
 	Region    string `json:"region"`
 	Domain    string `json:"domain"`
 	Private   bool   `json:"private"`
+	PathStyle bool   `json:"path_style"`
+}
+
+type GcConfigVO struct {
+	DryRun      bool `json:"dry_run"`
+	GracePeriod int  `json:"grace_period"`
 }
 
 type OtherConfigVO struct {
 	AllowOrigin string `json:"allow_origin"`
 	Prefix      string `json:"prefix"`
 }
+
+type TranscodingConfigVO struct {
+	Profiles        []EncodingProfileVO `json:"profiles"`
+	KeepAspectRatio bool                `json:"keep_aspect_ratio"`
+	Portrait        bool                `json:"portrait"`
+}
+
+type EncodingProfileVO struct {
+	Quality   int     `json:"quality"`
+	Bitrate   int     `json:"bitrate"`
+	Crf       int     `json:"crf"`
+	Codec     string  `json:"codec"`
+	Preset    string  `json:"preset"`
+	FpsPolicy string  `json:"fps_policy"`
+	Fps       float64 `json:"fps"`
+}
+
+func ToTranscodingConfigVO(config transcoding.EncodingConfig) TranscodingConfigVO {
+	length := len(config.Profiles)
+	profiles := make([]EncodingProfileVO, length)
+	for i := 0; i < length; i++ {
+		profiles[i].Quality = config.Profiles[i].Quality
+		profiles[i].Bitrate = config.Profiles[i].Bitrate
+		profiles[i].Crf = config.Profiles[i].Crf
+		profiles[i].Codec = config.Profiles[i].Codec
+		profiles[i].Preset = config.Profiles[i].Preset
+		profiles[i].FpsPolicy = config.Profiles[i].FpsPolicy
+		profiles[i].Fps = config.Profiles[i].Fps
+	}
+
+	return TranscodingConfigVO{
+		Profiles:        profiles,
+		KeepAspectRatio: config.KeepAspectRatio,
+		Portrait:        config.Portrait,
+	}
+}
@@ -1,18 +1,64 @@
 package vo
 
-import "clicli/domain/model"
+import (
+	"time"
+
+	"clicli/domain/model"
+)
 
 type DanmakuVO struct {
+	ID    uint   `json:"id"`
 	Time  uint   `json:"time"`
 	Type  int    `json:"type"`
 	Color string `json:"color"`
 	Text  string `json:"text"`
 }
 
+// 实时推送的弹幕
+type WsDanmakuVO struct {
+	DanmakuVO
+	Part uint `json:"part"`
+	Uid  uint `json:"uid"`
+}
+
+// 房间websocket消息
+type WsMessageVO struct {
+	// 消息类型 online:在线人数 danmaku:弹幕
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+func ToWsDanmakuVO(danmaku model.Danmaku) WsDanmakuVO {
+	return WsDanmakuVO{
+		DanmakuVO: DanmakuVO{
+			ID:    danmaku.ID,
+			Time:  danmaku.Time,
+			Type:  danmaku.Type,
+			Color: danmaku.Color,
+			Text:  danmaku.Text,
+		},
+		Part: danmaku.Part,
+		Uid:  danmaku.Uid,
+	}
+}
+
+func LiveDanmakuToWsDanmakuVO(danmaku model.LiveDanmaku) WsDanmakuVO {
+	return WsDanmakuVO{
+		DanmakuVO: DanmakuVO{
+			Time:  danmaku.Time,
+			Type:  danmaku.Type,
+			Color: danmaku.Color,
+			Text:  danmaku.Text,
+		},
+		Uid: danmaku.Uid,
+	}
+}
+
 func ToDanmakuVoList(danmaku []model.Danmaku) []DanmakuVO {
 	length := len(danmaku)
 	newDanmaku := make([]DanmakuVO, length)
 	for i := 0; i < length; i++ {
+		newDanmaku[i].ID = danmaku[i].ID
 		newDanmaku[i].Time = danmaku[i].Time
 		newDanmaku[i].Type = danmaku[i].Type
 		newDanmaku[i].Color = danmaku[i].Color
@@ -21,3 +67,52 @@ func ToDanmakuVoList(danmaku []model.Danmaku) []DanmakuVO {
 
 	return newDanmaku
 }
+
+// 管理员查看的弹幕
+type DanmakuManageVO struct {
+	DanmakuVO
+	Vid       uint       `json:"vid"`
+	Part      uint       `json:"part"`
+	Author    BaseUserVO `json:"author"`
+	Reports   int64      `json:"reports"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// 违禁词
+type FilterWordVO struct {
+	ID        uint      `json:"id"`
+	Word      string    `json:"word"`
+	Regex     bool      `json:"regex"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ToDanmakuManageVO(danmaku model.Danmaku, author model.User, reports int64) DanmakuManageVO {
+	return DanmakuManageVO{
+		DanmakuVO: DanmakuVO{
+			ID:    danmaku.ID,
+			Time:  danmaku.Time,
+			Type:  danmaku.Type,
+			Color: danmaku.Color,
+			Text:  danmaku.Text,
+		},
+		Vid:       danmaku.Vid,
+		Part:      danmaku.Part,
+		Author:    ToBaseUserVO(author),
+		Reports:   reports,
+		CreatedAt: danmaku.CreatedAt,
+	}
+}
+
+func ToFilterWordVoList(words []model.FilterWord) []FilterWordVO {
+	newWords := make([]FilterWordVO, len(words))
+	for i, word := range words {
+		newWords[i] = FilterWordVO{
+			ID:        word.ID,
+			Word:      word.Word,
+			Regex:     word.Regex,
+			CreatedAt: word.CreatedAt,
+		}
+	}
+
+	return newWords
+}
@@ -0,0 +1,52 @@
+package vo
+
+import (
+	"time"
+
+	"clicli/domain/model"
+)
+
+// 直播间
+type LiveRoomVO struct {
+	ID     uint       `json:"id"`
+	Title  string     `json:"title"`
+	Cover  string     `json:"cover"`
+	Author BaseUserVO `json:"author"`
+	// 是否正在直播
+	Online bool `json:"online"`
+	// 开播时间
+	StartedAt *time.Time `json:"started_at"`
+	// 播放地址
+	FlvUrl string `json:"flv_url"`
+	HlsUrl string `json:"hls_url"`
+}
+
+// 主播自己的直播间
+type LiveRoomOwnerVO struct {
+	LiveRoomVO
+	// 推流地址
+	PushUrl string `json:"push_url"`
+	// 推流名称
+	StreamName string `json:"stream_name"`
+	// 推流密钥，为空表示已吊销
+	StreamKey string `json:"stream_key"`
+}
+
+func ToLiveRoomVO(room model.LiveRoom, startTime int64, flvUrl, hlsUrl string) LiveRoomVO {
+	vo := LiveRoomVO{
+		ID:     room.ID,
+		Title:  room.Title,
+		Cover:  room.Cover,
+		Author: ToBaseUserVO(room.Author),
+		Online: startTime > 0,
+	}
+
+	// 只有直播中才返回播放地址
+	if vo.Online {
+		t := time.Unix(startTime, 0)
+		vo.StartedAt = &t
+		vo.FlvUrl = flvUrl
+		vo.HlsUrl = hlsUrl
+	}
+	return vo
+}
@@ -1,13 +1,25 @@
 package vo
 
-import "clicli/domain/model"
+import (
+	"strings"
+
+	"clicli/domain/model"
+)
 
 type ResourceVO struct {
 	ID uint `json:"id"`
 	// 分P使用的标题
 	Title string `json:"title"`
-	// 不同分辨率
+	// 不同分辨率(DASH)
 	Url string `json:"url"`
+	// HLS播放列表
+	HlsUrl string `json:"hls_url"`
+	// 进度条预览图(WebVTT)
+	Storyboard string `json:"storyboard"`
+	// 自动生成的候选封面
+	Covers []string `json:"covers"`
+	// 字幕(WebVTT)
+	Subtitles []SubtitleVO `json:"subtitles"`
 	// 时长
 	Duration float64 `json:"duration"`
 	// 审核状态
@@ -23,6 +35,13 @@ func ToResourceVoList(resources []model.Resource) []ResourceVO {
 		newResources[i].ID = resources[i].ID
 		newResources[i].Title = resources[i].Title
 		newResources[i].Url = resources[i].Url
+		newResources[i].HlsUrl = resources[i].HlsUrl
+		newResources[i].Storyboard = resources[i].Storyboard
+		newResources[i].Covers = make([]string, 0)
+		if resources[i].Covers != "" {
+			newResources[i].Covers = strings.Split(resources[i].Covers, ",")
+		}
+		newResources[i].Subtitles = ToSubtitleVoList(resources[i].Subtitles)
 		newResources[i].Duration = resources[i].Duration
 		newResources[i].Status = resources[i].Status
 		newResources[i].Quality = resources[i].Quality
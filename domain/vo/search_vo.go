@@ -0,0 +1,51 @@
+package vo
+
+import "clicli/search"
+
+// 搜索结果的分面统计
+type SearchFacetVO struct {
+	// 各分区的视频数量
+	Partitions []PartitionFacetVO `json:"partitions"`
+	// 各时长区间的视频数量
+	Durations []DurationFacetVO `json:"durations"`
+}
+
+type PartitionFacetVO struct {
+	Partition uint `json:"partition"`
+	Count     int  `json:"count"`
+}
+
+type DurationFacetVO struct {
+	// 时长区间 1:10分钟以下 2:10-30分钟 3:30-60分钟 4:60分钟以上
+	Duration int `json:"duration"`
+	Count    int `json:"count"`
+}
+
+func ToSearchFacetVO(facets map[string][]search.FacetCount) SearchFacetVO {
+	partitions := make([]PartitionFacetVO, len(facets["partition"]))
+	for i, facet := range facets["partition"] {
+		partitions[i] = PartitionFacetVO{Partition: uint(facet.Value), Count: facet.Count}
+	}
+
+	durations := make([]DurationFacetVO, len(facets["duration"]))
+	for i, facet := range facets["duration"] {
+		durations[i] = DurationFacetVO{Duration: int(facet.Value) + 1, Count: facet.Count}
+	}
+
+	return SearchFacetVO{Partitions: partitions, Durations: durations}
+}
+
+// 热门搜索
+type TrendingSearchVO struct {
+	Keyword string `json:"keyword"`
+	// 按时间衰减后的搜索次数
+	Score float64 `json:"score"`
+}
+
+// 后台查看的搜索词统计
+type HotSearchVO struct {
+	Keyword string `json:"keyword"`
+	Count   int64  `json:"count"`
+	// 是否包含屏蔽词
+	Blocked bool `json:"blocked"`
+}
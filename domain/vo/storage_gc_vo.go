@@ -0,0 +1,19 @@
+package vo
+
+type StorageGcReportVO struct {
+	// 只生成报告，不删除文件
+	DryRun bool `json:"dry_run"`
+	// 宽限期(小时)
+	GracePeriod int `json:"grace_period"`
+	// 清理的视频文件夹
+	Videos []string `json:"videos"`
+	// 清理的图片
+	Images []string `json:"images"`
+	// 清理的字幕
+	Subtitles []string `json:"subtitles"`
+	// 清理的文件数量
+	Objects int `json:"objects"`
+	// 清理的文件大小(字节)
+	Size   int64    `json:"size"`
+	Errors []string `json:"errors"`
+}
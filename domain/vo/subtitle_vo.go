@@ -0,0 +1,31 @@
+package vo
+
+import "clicli/domain/model"
+
+type SubtitleVO struct {
+	ID uint `json:"id"`
+	// 语言代码
+	Lang string `json:"lang"`
+	// 显示名称
+	Label string `json:"label"`
+	// WebVTT字幕
+	Url string `json:"url"`
+}
+
+func ToSubtitleVO(subtitle model.Subtitle) SubtitleVO {
+	return SubtitleVO{
+		ID:    subtitle.ID,
+		Lang:  subtitle.Lang,
+		Label: subtitle.Label,
+		Url:   subtitle.Url,
+	}
+}
+
+func ToSubtitleVoList(subtitles []model.Subtitle) []SubtitleVO {
+	length := len(subtitles)
+	newSubtitles := make([]SubtitleVO, length)
+	for i := 0; i < length; i++ {
+		newSubtitles[i] = ToSubtitleVO(subtitles[i])
+	}
+	return newSubtitles
+}
@@ -0,0 +1,32 @@
+package vo
+
+// 转码进度消息类型
+const TRANSCODING_PROGRESS_TYPE = "transcoding"
+
+type TranscodingProgressVO struct {
+	// 消息类型，用于区分私信
+	Type string `json:"type"`
+	// 资源ID
+	Rid uint `json:"rid"`
+	// 视频ID
+	Vid uint `json:"vid"`
+	// 资源状态
+	Status int `json:"status"`
+	// 当前步骤
+	Step string `json:"step"`
+	// 当前步骤序号(从1开始)
+	StepIndex int `json:"step_index"`
+	// 总步骤数
+	StepCount int `json:"step_count"`
+	// 当前步骤进度(0-100)
+	Percent float64 `json:"percent"`
+	// 当前步骤预计剩余时间(秒)，-1表示未知
+	Eta int `json:"eta"`
+	// 不同分辨率的进度
+	Renditions []RenditionProgressVO `json:"renditions"`
+}
+
+type RenditionProgressVO struct {
+	Quality int     `json:"quality"`
+	Percent float64 `json:"percent"`
+}
@@ -0,0 +1,22 @@
+package vo
+
+import "clicli/domain/model"
+
+type UploadSessionVO struct {
+	ID         string `json:"id"`
+	Size       int64  `json:"size"`
+	ChunkSize  int64  `json:"chunk_size"`
+	ChunkCount int    `json:"chunk_count"`
+	// 已上传的分片序号
+	Uploaded []int `json:"uploaded"`
+}
+
+func ToUploadSessionVO(session model.UploadSession, uploaded []int) UploadSessionVO {
+	return UploadSessionVO{
+		ID:         session.ID,
+		Size:       session.Size,
+		ChunkSize:  session.ChunkSize,
+		ChunkCount: session.ChunkCount,
+		Uploaded:   uploaded,
+	}
+}
@@ -16,6 +16,13 @@ type BaseUserVO struct {
 	Gender     int    `json:"gender"`
 }
 
+// 搜索用户
+type SearchUserVO struct {
+	BaseUserVO
+	// 高亮的用户名和签名
+	Highlight map[string]string `json:"highlight"`
+}
+
 type UserVO struct {
 	ID         uint      `json:"uid"`
 	Name       string    `json:"name"`
@@ -91,3 +98,13 @@ func ToBaseUserVoList(users []model.User) []BaseUserVO {
 
 	return newUsers
 }
+
+func ToSearchUserVoList(users []model.User, highlights []map[string]string) []SearchUserVO {
+	length := len(users)
+	newUsers := make([]SearchUserVO, length)
+	for i := 0; i < length; i++ {
+		newUsers[i].BaseUserVO = ToBaseUserVO(users[i])
+		newUsers[i].Highlight = highlights[i]
+	}
+	return newUsers
+}
@@ -4,7 +4,6 @@ import (
 	"time"
 
 	"clicli/domain/model"
-	"github.com/spf13/viper"
 )
 
 type RoomVO struct {
@@ -43,8 +42,6 @@ type BaseVideoVO struct {
 	Desc      string    `json:"desc"`
 	Clicks    int64     `json:"clicks"`
 	CreatedAt time.Time `json:"created_at"`
-	Video     int       `json:"video"`
-	Flv       string    `json:"flv"`
 }
 
 // 用户上传视频
@@ -56,8 +53,6 @@ type UserUploadVideoVO struct {
 	Clicks    int64     `json:"clicks"`
 	Status    int       `json:"status"`
 	CreatedAt time.Time `json:"created_at"`
-	Video     int       `json:"video"`
-	Flv       string    `json:"flv"`
 }
 
 // 搜索视频
@@ -66,13 +61,13 @@ type SearchVideoVO struct {
 	Title     string     `json:"title"`
 	Cover     string     `json:"cover"`
 	Desc      string     `json:"desc"`
-	Video     int        `json:"video"`
 	CreatedAt time.Time  `json:"created_at"`
 	Copyright bool       `json:"copyright"`
 	Author    BaseUserVO `json:"author"`
 	Clicks    int64      `json:"clicks"`
 	Partition uint       `json:"partition"`
-	Flv       string     `json:"flv"`
+	// 搜索时高亮的标题和简介
+	Highlight map[string]string `json:"highlight,omitempty"`
 }
 
 func ToVideoStatusVO(video model.Video, resources []model.Resource) VideoStatusVO {
@@ -90,15 +85,7 @@ func ToVideoStatusVO(video model.Video, resources []model.Resource) VideoStatusV
 	}
 }
 
-func ToVideoVO(video model.Video, resource []model.Resource, ios uint) VideoVO {
-
-	// 处理视频资源地址
-	if ios == 1 && viper.GetBool("file.video_adaptation_ios") {
-		for i := 0; i < len(resource); i++ {
-			resource[i].Url = resource[i].OriginalUrl
-		}
-	}
-
+func ToVideoVO(video model.Video, resource []model.Resource) VideoVO {
 	return VideoVO{
 		ID:        video.ID,
 		Title:     video.Title,
@@ -132,9 +119,7 @@ func ToBaseVideoVoList(videos []model.Video) []BaseVideoVO {
 		newVideos[i].Cover = videos[i].Cover
 		newVideos[i].Desc = videos[i].Desc
 		newVideos[i].Clicks = videos[i].Clicks
-		newVideos[i].Video = videos[i].Video
 		newVideos[i].CreatedAt = videos[i].CreatedAt
-		newVideos[i].Flv = videos[i].Flv
 	}
 
 	return newVideos
@@ -150,7 +135,6 @@ func ToUserUploadVideoVoList(videos []model.Video) []UserUploadVideoVO {
 		newVideos[i].Desc = videos[i].Desc
 		newVideos[i].Clicks = videos[i].Clicks
 		newVideos[i].Status = videos[i].Status
-		newVideos[i].Video = videos[i].Video
 		newVideos[i].CreatedAt = videos[i].CreatedAt
 	}
 
@@ -165,7 +149,6 @@ func ToSearchVideoVoList(videos []model.Video) []SearchVideoVO {
 		newVideos[i].Title = videos[i].Title
 		newVideos[i].Cover = videos[i].Cover
 		newVideos[i].Desc = videos[i].Desc
-		newVideos[i].Video = videos[i].Video
 		newVideos[i].Clicks = videos[i].Clicks
 		newVideos[i].Copyright = videos[i].Copyright
 		newVideos[i].CreatedAt = videos[i].CreatedAt
@@ -17,5 +17,15 @@ func ConfigFiles() {
 		viper.Set("server.jwt_secret", random.GenerateNumberCode(16))
 	}
 
+	// 文件签名密钥
+	if viper.GetString("security.url_secret") == "" {
+		viper.Set("security.url_secret", random.GenerateNumberCode(32))
+	}
+
+	// 直播回调密钥，SRS回调地址需要带上 ?secret=<密钥>
+	if viper.GetString("live.callback_secret") == "" {
+		viper.Set("live.callback_secret", random.GenerateNumberCode(32))
+	}
+
 	viper.WriteConfig()
 }
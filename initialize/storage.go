@@ -0,0 +1,25 @@
+package initialize
+
+import (
+	"clicli/storage"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+func Storage() {
+	config := storage.Config{
+		KeyID:     viper.GetString("oss.key_id"),
+		KeySecret: viper.GetString("oss.key_secret"),
+		Bucket:    viper.GetString("oss.bucket"),
+		Endpoint:  viper.GetString("oss.endpoint"),
+		AppID:     viper.GetString("oss.app_id"),
+		Region:    viper.GetString("oss.region"),
+		Domain:    viper.GetString("oss.domain"),
+		Private:   viper.GetBool("oss.private"),
+		PathStyle: viper.GetBool("oss.path_style"),
+	}
+
+	if err := storage.NewStorage(viper.GetString("oss.type"), config); err != nil {
+		zap.L().Error("初始化存储-" + viper.GetString("oss.type") + "失败，err: " + err.Error())
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"clicli/service"
 	"clicli/util/authentication"
 	"clicli/util/cron"
+	"clicli/ws"
 )
 
 func main() {
@@ -19,8 +20,8 @@ func main() {
 	logger.InitLogger()
 	// 初始化滑块验证码生成
 	initialize.Jigsaw()
-	// 初始化OSS
-	initialize.Oss()
+	// 初始化存储
+	initialize.Storage()
 	// 初始化casbin
 	authentication.InitCasbin()
 	// 初始化mysql
@@ -31,10 +32,20 @@ func main() {
 	mongodb.Init()
 	// 初始化缓存
 	cache.Init()
+	// 初始化websocket跨节点消息
+	ws.SetBus(ws.NewRedisBus())
 	// 初始化mysql客户端
 	service.InitMysqlClient()
 	// 初始化mongodb客户端
 	service.InitMongoClient()
+	// 初始化转码队列
+	service.InitTransCodingQueue()
+	// 处理未完成的直播录像
+	service.InitLiveRecord()
+	// 订阅索引更新并在后台建立搜索索引
+	service.InitSearch()
+	// 没有推荐数据时在后台计算
+	service.InitRecommend()
 	// 开启定时任务
 	go cron.Init()
 
@@ -48,6 +48,23 @@ func Auth() gin.HandlerFunc {
 	}
 }
 
+// 可选登录，token有效时设置用户ID，否则作为未登录用户继续处理
+func OptionalAuth() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tokenString := ctx.GetHeader("Authorization")
+		if tokenString == "" {
+			ctx.Next()
+			return
+		}
+
+		_, claims, err := jwt.ParseToken(tokenString)
+		if err == nil && cache.IsTokenExist(claims.UserId, tokenString) {
+			ctx.Set("userId", claims.UserId)
+		}
+		ctx.Next()
+	}
+}
+
 func WsAuth() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// 读取验证token
@@ -0,0 +1,111 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+)
+
+// 推荐结果或相似视频
+type Item struct {
+	ID    uint
+	Score float64
+}
+
+/**
+ * 基于物品的协同过滤，计算每个视频最相似的视频
+ * interactions: 用户ID -> 视频ID -> 行为权重(观看、点赞、收藏的权重之和)
+ * neighbors: 每个视频保留的相似视频数量
+ * 相似度使用余弦相似度，活跃用户的贡献按行为数量的对数降低
+ */
+func Similarity(interactions map[uint]map[uint]float64, neighbors int) map[uint][]Item {
+	norms := make(map[uint]float64)
+	cooccurrence := make(map[uint]map[uint]float64)
+	for _, items := range interactions {
+		if len(items) == 0 {
+			continue
+		}
+
+		penalty := 1 / math.Log(2+float64(len(items)))
+		for i, wi := range items {
+			norms[i] += wi * wi
+			for j, wj := range items {
+				if i == j {
+					continue
+				}
+				if cooccurrence[i] == nil {
+					cooccurrence[i] = make(map[uint]float64)
+				}
+				cooccurrence[i][j] += wi * wj * penalty
+			}
+		}
+	}
+
+	similar := make(map[uint][]Item, len(cooccurrence))
+	for i, related := range cooccurrence {
+		items := make([]Item, 0, len(related))
+		for j, score := range related {
+			items = append(items, Item{ID: j, Score: score / math.Sqrt(norms[i]*norms[j])})
+		}
+		similar[i] = Top(items, neighbors)
+	}
+	return similar
+}
+
+/**
+ * 根据用户的行为和视频相似度计算推荐结果
+ * userItems: 视频ID -> 行为权重
+ * exclude: 不推荐的视频，如已观看的视频
+ */
+func Recommend(userItems map[uint]float64, similar map[uint][]Item, exclude map[uint]bool, limit int) []Item {
+	scores := make(map[uint]float64)
+	for i, weight := range userItems {
+		for _, item := range similar[i] {
+			if exclude[item.ID] {
+				continue
+			}
+			if _, ok := userItems[item.ID]; ok {
+				continue
+			}
+			scores[item.ID] += weight * item.Score
+		}
+	}
+
+	items := make([]Item, 0, len(scores))
+	for id, score := range scores {
+		items = append(items, Item{ID: id, Score: score})
+	}
+	return Top(items, limit)
+}
+
+// 每个用户只保留权重最高的limit个视频，限制计算相似度时每个用户产生的视频组合数量
+func LimitInteractions(interactions map[uint]map[uint]float64, limit int) {
+	for userId, items := range interactions {
+		if len(items) <= limit {
+			continue
+		}
+
+		list := make([]Item, 0, len(items))
+		for id, weight := range items {
+			list = append(list, Item{ID: id, Score: weight})
+		}
+		kept := make(map[uint]float64, limit)
+		for _, item := range Top(list, limit) {
+			kept[item.ID] = item.Score
+		}
+		interactions[userId] = kept
+	}
+}
+
+// 按分数从高到低排序，分数相同时新视频(ID较大)在前，保留limit个
+func Top(items []Item, limit int) []Item {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		return items[i].ID > items[j].ID
+	})
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,42 @@
+package recommend
+
+import "math"
+
+// 相关视频的候选
+type RelatedCandidate struct {
+	ID            uint
+	SamePartition bool
+	SameAuthor    bool
+	// 标题和简介的相关度，0-1
+	Text float64
+	// 同时观看过的用户比例，0-1
+	CoWatch float64
+	Clicks  int64
+}
+
+// 相关视频各项信号的权重，热度按播放量的对数计算
+type RelatedWeights struct {
+	Partition  float64
+	Author     float64
+	Text       float64
+	CoWatch    float64
+	Popularity float64
+}
+
+// 按加权后的分数对候选排序，保留limit个
+func Related(candidates []RelatedCandidate, weights RelatedWeights, limit int) []Item {
+	items := make([]Item, 0, len(candidates))
+	for _, c := range candidates {
+		score := weights.Text*c.Text + weights.CoWatch*c.CoWatch
+		if c.SamePartition {
+			score += weights.Partition
+		}
+		if c.SameAuthor {
+			score += weights.Author
+		}
+		score += weights.Popularity * math.Log10(1+float64(c.Clicks))
+
+		items = append(items, Item{ID: c.ID, Score: score})
+	}
+	return Top(items, limit)
+}
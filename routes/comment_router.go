@@ -13,6 +13,8 @@ func CollectCommentRoutes(r *gin.RouterGroup) {
 		comment.GET("get", api.GetComment)
 		// 获取回复
 		comment.GET("reply/get", api.GetReply)
+		// 搜索评论和回复
+		comment.GET("search", api.SearchComment)
 
 		auth := comment.Group("")
 		auth.Use(middleware.Auth())
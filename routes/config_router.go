@@ -18,6 +18,16 @@ func CollectConfigRoutes(r *gin.RouterGroup) {
 		config.GET("storage/get", api.GetStorageConfig)
 		// 修改存储配置
 		config.POST("storage/set", api.SetStorageConfig)
+		// 获取转码配置
+		config.GET("transcoding/get", api.GetTranscodingConfig)
+		// 修改转码配置
+		config.POST("transcoding/set", api.SetTranscodingConfig)
+		// 获取存储清理配置
+		config.GET("gc/get", api.GetGcConfig)
+		// 修改存储清理配置
+		config.POST("gc/set", api.SetGcConfig)
+		// 获取存储清理报告
+		config.GET("gc/report", api.GetGcReport)
 		// 获取其他配置
 		config.GET("other/get", api.GetOtherConfig)
 		// 修改其他配置
@@ -11,6 +11,8 @@ func CollectDanmakuRoutes(r *gin.RouterGroup) {
 	{
 		// 获取弹幕列表
 		danmaku.GET("list", api.GetDanmaku)
+		// 导出弹幕
+		danmaku.GET("export", api.ExportDanmaku)
 
 		//需要用户登录
 		auth := danmaku.Group("")
@@ -18,6 +20,30 @@ func CollectDanmakuRoutes(r *gin.RouterGroup) {
 		{
 			// 发送弹幕
 			auth.POST("send", api.SendDanmaku)
+			// 删除弹幕(发送者或视频作者)
+			auth.POST("delete", api.DeleteDanmaku)
+			// 举报弹幕
+			auth.POST("report", api.ReportDanmaku)
+			// 导入弹幕(视频作者)
+			auth.POST("import", api.ImportDanmaku)
+
+			manage := auth.Group("manage")
+			{
+				// 搜索弹幕
+				manage.GET("list", api.AdminGetDanmakuList)
+				// 获取被举报的弹幕
+				manage.GET("report/list", api.AdminGetReportedDanmaku)
+				// 忽略举报
+				manage.POST("report/ignore", api.AdminIgnoreDanmakuReport)
+				// 批量删除弹幕
+				manage.POST("delete", api.AdminDeleteDanmaku)
+				// 禁止用户发送弹幕
+				manage.POST("mute", api.AdminMuteDanmakuUser)
+				// 违禁词
+				manage.GET("filter/list", api.AdminGetFilterWordList)
+				manage.POST("filter/add", api.AdminAddFilterWord)
+				manage.POST("filter/delete", api.AdminDeleteFilterWord)
+			}
 		}
 	}
 }
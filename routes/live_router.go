@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"clicli/api/v1"
+	"clicli/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func CollectLiveRoutes(route *gin.RouterGroup) {
+	live := route.Group("/live")
+	{
+		//获取在线直播列表
+		live.GET("/list", api.GetLiveList)
+		//获取直播间信息
+		live.GET("/get", api.GetLiveRoom)
+		//直播间websocket
+		live.GET("/online/ws", api.GetLiveConnect)
+		//媒体服务器推流回调
+		live.POST("/callback/publish", api.LivePublishCallback)
+		live.POST("/callback/unpublish", api.LiveUnpublishCallback)
+		live.POST("/callback/dvr", api.LiveDvrCallback)
+
+		auth := live.Group("")
+		auth.Use(middleware.Auth())
+		{
+			//获取自己的直播间
+			auth.GET("/room/get", api.GetOwnLiveRoom)
+			//修改直播间信息
+			auth.POST("/room/modify", api.ModifyLiveRoom)
+			//重新生成推流密钥
+			auth.POST("/key/rotate", api.RotateStreamKey)
+			//吊销推流密钥
+			auth.POST("/key/revoke", api.RevokeStreamKey)
+			//发送直播弹幕
+			auth.POST("/danmaku/send", api.SendLiveDanmaku)
+		}
+	}
+}
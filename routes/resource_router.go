@@ -15,6 +15,12 @@ func CollectResourceRoutes(r *gin.RouterGroup) {
 		{
 			auth.POST("title/modify", api.ModifyResourceTitle)
 			auth.POST("delete", api.DeleteResource)
+			// 获取转码进度
+			auth.GET("progress", api.GetResourceProgress)
+			// 字幕
+			auth.POST("subtitle/upload", api.UploadSubtitle)
+			auth.POST("subtitle/modify", api.ModifySubtitle)
+			auth.POST("subtitle/delete", api.DeleteSubtitle)
 		}
 	}
 }
@@ -1,13 +1,24 @@
 package routes
 
 import (
+	"context"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"clicli/api/v1"
 	"clicli/logger"
 	"clicli/middleware"
+	"clicli/ws"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
+// 关闭服务时等待请求处理完成的时间
+const SHUTDOWN_TIMEOUT = 10 * time.Second
+
 func InitRouter() {
 	// gin 模式
 	gin.SetMode(gin.ReleaseMode)
@@ -24,7 +35,28 @@ func InitRouter() {
 	CollectRoutes(r)
 
 	// 运行
-	r.Run(":9000")
+	srv := &http.Server{
+		Addr:    ":9000",
+		Handler: r,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			zap.L().Fatal("启动服务失败" + err.Error())
+		}
+	}()
+
+	// 收到退出信号后关闭websocket连接，等待其他请求处理完成
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	zap.L().Info("正在关闭服务")
+
+	ws.Shutdown()
+	ctx, cancel := context.WithTimeout(context.Background(), SHUTDOWN_TIMEOUT)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		zap.L().Error("关闭服务失败" + err.Error())
+	}
 }
 
 func CollectRoutes(r *gin.Engine) *gin.Engine {
@@ -57,17 +89,22 @@ func CollectRoutes(r *gin.Engine) *gin.Engine {
 		CollectHistoryRoutes(v1)
 		// 弹幕相关路由
 		CollectDanmakuRoutes(v1)
+		// 直播相关路由
+		CollectLiveRoutes(v1)
 		// 轮播图相关路由
 		CollectCarouselRoutes(v1)
 		// 网站配置相关路由
 		CollectConfigRoutes(v1)
 		// 仪表盘相关路由
 		CollectDashboardRoutes(v1)
+		// 搜索建议和热门搜索相关路由
+		CollectSearchRoutes(v1)
 	}
 
-	//获取静态文件
-	r.StaticFS("/api/image", http.Dir("./upload/image"))
-	r.StaticFS("/api/video", http.Dir("./upload/video"))
+	//获取静态文件，视频和字幕需要通过签名链接访问
+	// 图片(头像、封面、轮播图等)在未登录时也需要展示，仍然公开访问，但不允许列出目录中的文件
+	r.StaticFS("/api/image", gin.Dir("./upload/image", false))
+	r.GET("/api/file/:expires/:signature/*key", api.GetSignedFile)
 	r.StaticFS("/api/config", http.Dir("./upload/config"))
 
 	return r
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"clicli/api/v1"
+	"clicli/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func CollectSearchRoutes(r *gin.RouterGroup) {
+	search := r.Group("search")
+	{
+		// 获取搜索建议
+		search.GET("suggest", api.GetSearchSuggest)
+		// 获取热门搜索
+		search.GET("trending", api.GetTrendingSearch)
+
+		//需要用户登录
+		auth := search.Group("")
+		auth.Use(middleware.Auth())
+		{
+			manage := auth.Group("manage")
+			{
+				// 按搜索次数获取搜索词
+				manage.GET("hot", api.AdminGetHotSearchList)
+				// 搜索建议屏蔽词
+				manage.GET("block/list", api.AdminGetSearchBlockList)
+				manage.POST("block/add", api.AdminAddSearchBlock)
+				manage.POST("block/delete", api.AdminDeleteSearchBlock)
+			}
+		}
+	}
+}
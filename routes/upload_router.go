@@ -14,6 +14,10 @@ func CollectUploadRoutes(r *gin.RouterGroup) {
 		{
 			auth.POST("image", api.UploadImg)
 			auth.POST("video/:vid", api.UploadVideo)
+			auth.POST("video/chunk/init", api.InitChunkUpload)
+			auth.POST("video/chunk", api.UploadChunk)
+			auth.GET("video/chunk/status", api.GetChunkUploadStatus)
+			auth.POST("video/chunk/complete", api.CompleteChunkUpload)
 		}
 	}
 }
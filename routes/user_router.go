@@ -21,6 +21,8 @@ func CollectUserRoutes(r *gin.RouterGroup) {
 		user.GET("info/other", api.GetUserInfoByID)
 		// 通过用户名获取用户ID
 		user.GET("uid", api.GetUserIdByName)
+		// 搜索用户
+		user.GET("search", api.SearchUser)
 		// 验证修改密码的用户
 		user.GET("resetpwd/check", api.ResetPwdCheck)
 		// 修改密码
@@ -13,22 +13,16 @@ func CollectVideoRoutes(r *gin.RouterGroup) {
 		video.GET("get", api.GetVideoByID)
 		// 获取视频列表
 		video.GET("list", api.GetVideoList)
-		// 获取推荐视频列表
-		video.GET("recommended", api.GetRecommendedVideo)
+		// 获取推荐视频列表(可选登录)
+		video.GET("recommended", middleware.OptionalAuth(), api.GetRecommendedVideo)
+		// 获取相关视频列表
+		video.GET("related", api.GetRelatedVideo)
 		// 搜索视频
 		video.GET("search", api.SearchVideo)
 		// 获取用户视频
 		video.GET("user/get", api.GetVideoListByUid)
 		//在线人数连接
 		video.GET("online/ws", api.GetRoomConnect)
-
-		video.POST("flvkey/submit", api.SubmitFlvkey)
-
-		video.POST("url/get", api.GetVideoFlv)
-
-		video.GET("live/get", api.GetUserLikeList)
-
-		video.GET("live", api.GetLike)
 		//需要用户登录
 		auth := video.Group("")
 		auth.Use(middleware.Auth())
@@ -58,8 +52,6 @@ func CollectVideoRoutes(r *gin.RouterGroup) {
 			manage.GET("search", api.AdminSearchVideo)
 			// 管理员删除视频
 			manage.POST("delete", api.AdminDeleteVideo)
-
-			manage.GET("livereview/list", api.GetReviewLiveList)
 			// 获取审核列表
 			manage.GET("review/list", api.GetReviewVideoList)
 			// 获取待审核视频资源
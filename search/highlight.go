@@ -0,0 +1,74 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+// 长文本高亮时截取的长度
+const FRAGMENT_SIZE = 100
+
+// 高亮片段在第一个匹配位置之前保留的长度
+const FRAGMENT_BEFORE = 20
+
+const (
+	HIGHLIGHT_PRE  = "<em>"
+	HIGHLIGHT_POST = "</em>"
+)
+
+// 高亮匹配的词，其余内容做HTML转义，长文本截取第一个匹配附近的片段
+func Highlight(text string, terms []string) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = normalize(r)
+	}
+
+	marked := make([]bool, len(runes))
+	first := -1
+	for _, term := range terms {
+		t := []rune(term)
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if string(lower[i:i+len(t)]) != term {
+				continue
+			}
+			for j := i; j < i+len(t); j++ {
+				marked[j] = true
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+		}
+	}
+
+	start, end := 0, len(runes)
+	if len(runes) > FRAGMENT_SIZE {
+		if first > FRAGMENT_BEFORE {
+			start = first - FRAGMENT_BEFORE
+		}
+		if start+FRAGMENT_SIZE < end {
+			end = start + FRAGMENT_SIZE
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("...")
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && marked[j] == marked[i] {
+			j++
+		}
+		if marked[i] {
+			b.WriteString(HIGHLIGHT_PRE + html.EscapeString(string(runes[i:j])) + HIGHLIGHT_POST)
+		} else {
+			b.WriteString(html.EscapeString(string(runes[i:j])))
+		}
+		i = j
+	}
+	if end < len(runes) {
+		b.WriteString("...")
+	}
+	return b.String()
+}
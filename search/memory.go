@@ -0,0 +1,385 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BM25参数
+const (
+	BM25_K1 = 1.2
+	BM25_B  = 0.75
+)
+
+// 时间衰减的半衰期(天)
+const RECENCY_HALF_LIFE = 30
+
+type memoryDoc struct {
+	Document
+	terms   map[string]map[string]int // 字段 -> 词 -> 词频
+	lengths map[string]int            // 字段 -> 词数
+}
+
+type memoryIndex struct {
+	docs     map[string]*memoryDoc
+	postings map[string]map[string]map[string]int // 字段 -> 词 -> 文档ID -> 词频
+	lengths  map[string]int                       // 字段 -> 总词数
+}
+
+/**
+ * 内存倒排索引，服务启动时从数据库重建
+ * 每个节点都保存一份完整的索引，原文和倒排表都在内存中，40字左右的文档每条约占用2KB内存
+ */
+type memoryEngine struct {
+	mu      sync.RWMutex
+	indexes map[string]*memoryIndex
+}
+
+func NewMemory() Engine {
+	return &memoryEngine{indexes: make(map[string]*memoryIndex)}
+}
+
+func newMemoryIndex() *memoryIndex {
+	return &memoryIndex{
+		docs:     make(map[string]*memoryDoc),
+		postings: make(map[string]map[string]map[string]int),
+		lengths:  make(map[string]int),
+	}
+}
+
+func (e *memoryEngine) Index(index string, doc Document) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	idx, ok := e.indexes[index]
+	if !ok {
+		idx = newMemoryIndex()
+		e.indexes[index] = idx
+	}
+	idx.add(doc)
+	return nil
+}
+
+func (e *memoryEngine) Delete(index, id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if idx, ok := e.indexes[index]; ok {
+		idx.remove(id)
+	}
+	return nil
+}
+
+func (e *memoryEngine) UpdateAttrs(index, id string, attrs map[string]float64, popularity float64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	idx, ok := e.indexes[index]
+	if !ok {
+		return nil
+	}
+	doc, ok := idx.docs[id]
+	if !ok {
+		return nil
+	}
+
+	// 复制后修改，避免修改调用方传入的文档
+	updated := make(map[string]float64, len(doc.Attrs)+len(attrs))
+	for k, v := range doc.Attrs {
+		updated[k] = v
+	}
+	for k, v := range attrs {
+		updated[k] = v
+	}
+	doc.Attrs = updated
+	doc.Popularity = popularity
+	return nil
+}
+
+func (e *memoryEngine) Rebuild(index string, docs []Document) error {
+	idx := newMemoryIndex()
+	for _, doc := range docs {
+		idx.add(doc)
+	}
+
+	e.mu.Lock()
+	e.indexes[index] = idx
+	e.mu.Unlock()
+	return nil
+}
+
+func (e *memoryEngine) Search(query Query) (Result, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	idx, ok := e.indexes[query.Index]
+	if !ok {
+		return Result{Hits: make([]Hit, 0), Facets: newFacetCounter(query.Facets).result()}, nil
+	}
+
+	terms := QueryTerms(query.Keywords)
+	scores := idx.match(terms, query.Fields, query.MinimumMatch)
+
+	now := time.Now()
+	facets := newFacetCounter(query.Facets)
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		doc := idx.docs[id]
+		failed := failedFilters(doc.Attrs, query)
+		facets.add(doc.Attrs, failed)
+		if len(failed) > 0 {
+			continue
+		}
+
+		if query.PopularityWeight > 0 && doc.Popularity > 0 {
+			score *= 1 + query.PopularityWeight*math.Log10(1+doc.Popularity)
+		}
+		if query.RecencyWeight > 0 && !doc.Time.IsZero() {
+			days := now.Sub(doc.Time).Hours() / 24
+			score *= 1 + query.RecencyWeight*math.Pow(0.5, math.Max(days, 0)/RECENCY_HALF_LIFE)
+		}
+
+		hits = append(hits, Hit{Document: doc.Document, Score: score})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if query.Sort != "" {
+			if a, b := hits[i].Attrs[query.Sort], hits[j].Attrs[query.Sort]; a != b {
+				return a > b
+			}
+		}
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if !hits[i].Time.Equal(hits[j].Time) {
+			return hits[i].Time.After(hits[j].Time)
+		}
+		return hits[i].ID > hits[j].ID
+	})
+
+	result := Result{Total: len(hits), Hits: page(hits, query.Offset, query.Limit), Facets: facets.result()}
+	for i := range result.Hits {
+		result.Hits[i].Highlights = make(map[string]string, len(query.Highlight))
+		for _, field := range query.Highlight {
+			if text, ok := result.Hits[i].Fields[field]; ok {
+				result.Hits[i].Highlights[field] = Highlight(text, terms)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (idx *memoryIndex) add(doc Document) {
+	idx.remove(doc.ID)
+
+	d := &memoryDoc{
+		Document: doc,
+		terms:    make(map[string]map[string]int, len(doc.Fields)),
+		lengths:  make(map[string]int, len(doc.Fields)),
+	}
+	for field, text := range doc.Fields {
+		tokens := Tokenize(text)
+		freq := make(map[string]int)
+		for _, token := range tokens {
+			freq[token]++
+		}
+		d.terms[field] = freq
+		d.lengths[field] = len(tokens)
+		idx.lengths[field] += len(tokens)
+
+		if idx.postings[field] == nil {
+			idx.postings[field] = make(map[string]map[string]int)
+		}
+		for term, tf := range freq {
+			if idx.postings[field][term] == nil {
+				idx.postings[field][term] = make(map[string]int)
+			}
+			idx.postings[field][term][doc.ID] = tf
+		}
+	}
+	idx.docs[doc.ID] = d
+}
+
+func (idx *memoryIndex) remove(id string) {
+	d, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+
+	for field, freq := range d.terms {
+		idx.lengths[field] -= d.lengths[field]
+		for term := range freq {
+			delete(idx.postings[field][term], id)
+			if len(idx.postings[field][term]) == 0 {
+				delete(idx.postings[field], term)
+			}
+		}
+	}
+	delete(idx.docs, id)
+}
+
+/**
+ * 使用BM25计算匹配文档的相关度
+ * 默认查询词不超过2个时需要全部匹配，否则需要匹配75%，minimumMatch不为0时按指定比例匹配(至少匹配1个)
+ * 没有查询词时返回全部文档
+ */
+func (idx *memoryIndex) match(terms []string, fields map[string]float64, minimumMatch float64) map[string]float64 {
+	scores := make(map[string]float64)
+	if len(terms) == 0 {
+		for id := range idx.docs {
+			scores[id] = 1
+		}
+		return scores
+	}
+
+	if len(fields) == 0 {
+		fields = make(map[string]float64, len(idx.postings))
+		for field := range idx.postings {
+			fields[field] = 1
+		}
+	}
+
+	total := float64(len(idx.docs))
+	matched := make(map[string]int)
+	for _, term := range terms {
+		termDocs := make(map[string]bool)
+		for field, weight := range fields {
+			postings := idx.postings[field][term]
+			if len(postings) == 0 {
+				continue
+			}
+
+			df := float64(len(postings))
+			idf := math.Log(1 + (total-df+0.5)/(df+0.5))
+			avg := float64(idx.lengths[field]) / total
+			for id, tf := range postings {
+				length := float64(idx.docs[id].lengths[field])
+				norm := float64(tf) * (BM25_K1 + 1) / (float64(tf) + BM25_K1*(1-BM25_B+BM25_B*length/avg))
+				scores[id] += weight * idf * norm
+				termDocs[id] = true
+			}
+		}
+		for id := range termDocs {
+			matched[id]++
+		}
+	}
+
+	required := len(terms)
+	if minimumMatch > 0 {
+		required = int(math.Max(math.Ceil(float64(required)*minimumMatch), 1))
+	} else if required > 2 {
+		required = int(math.Ceil(float64(required) * 0.75))
+	}
+	for id := range scores {
+		if matched[id] < required {
+			delete(scores, id)
+		}
+	}
+	return scores
+}
+
+// 返回不满足的过滤条件对应的属性
+func failedFilters(attrs map[string]float64, query Query) map[string]bool {
+	var failed map[string]bool
+	fail := func(attr string) {
+		if failed == nil {
+			failed = make(map[string]bool)
+		}
+		failed[attr] = true
+	}
+
+	for attr, value := range query.Filters {
+		if v, ok := attrs[attr]; !ok || v != value {
+			fail(attr)
+		}
+	}
+	for _, r := range query.Ranges {
+		if v, ok := attrs[r.Attr]; !ok || !r.contains(v) {
+			fail(r.Attr)
+		}
+	}
+	return failed
+}
+
+func (r Range) contains(value float64) bool {
+	return value >= r.Min && (r.Max == 0 || value < r.Max)
+}
+
+type facetCounter struct {
+	facets []Facet
+	counts []map[float64]int
+}
+
+func newFacetCounter(facets []Facet) *facetCounter {
+	counts := make([]map[float64]int, len(facets))
+	for i := range facets {
+		counts[i] = make(map[float64]int)
+	}
+	return &facetCounter{facets: facets, counts: counts}
+}
+
+// 只有不满足的过滤条件都是该属性自身的条件时才计入统计
+func (c *facetCounter) add(attrs map[string]float64, failed map[string]bool) {
+	if len(failed) > 1 {
+		return
+	}
+
+	for i, facet := range c.facets {
+		if len(failed) == 1 && !failed[facet.Attr] {
+			continue
+		}
+
+		value, ok := attrs[facet.Attr]
+		if !ok {
+			continue
+		}
+
+		if len(facet.Ranges) == 0 {
+			c.counts[i][value]++
+			continue
+		}
+		for j, r := range facet.Ranges {
+			if r.contains(value) {
+				c.counts[i][float64(j)]++
+			}
+		}
+	}
+}
+
+// 按属性值排序，区间统计包括数量为0的区间
+func (c *facetCounter) result() map[string][]FacetCount {
+	result := make(map[string][]FacetCount, len(c.facets))
+	for i, facet := range c.facets {
+		counts := make([]FacetCount, 0, len(c.counts[i]))
+		if len(facet.Ranges) > 0 {
+			for j := range facet.Ranges {
+				counts = append(counts, FacetCount{Value: float64(j), Count: c.counts[i][float64(j)]})
+			}
+		} else {
+			for value, count := range c.counts[i] {
+				counts = append(counts, FacetCount{Value: value, Count: count})
+			}
+			sort.Slice(counts, func(a, b int) bool { return counts[a].Value < counts[b].Value })
+		}
+		result[facet.Attr] = counts
+	}
+	return result
+}
+
+// limit不大于0时返回空页，避免一次返回整个索引
+func page(hits []Hit, offset, limit int) []Hit {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 || offset >= len(hits) {
+		return make([]Hit, 0)
+	}
+	end := len(hits)
+	if offset+limit < end {
+		end = offset + limit
+	}
+	return hits[offset:end]
+}
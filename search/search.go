@@ -0,0 +1,119 @@
+package search
+
+import (
+	"sync"
+	"time"
+)
+
+// 索引名称
+const (
+	INDEX_VIDEO   = "video"
+	INDEX_USER    = "user"
+	INDEX_COMMENT = "comment"
+)
+
+// 搜索引擎接口，默认使用内存倒排索引，之后可以替换为ES等外部服务
+type Engine interface {
+	// 添加或更新文档
+	Index(index string, doc Document) error
+	// 删除文档
+	Delete(index, id string) error
+	// 更新文档的数值属性和热度，不重新分词，文档不存在时忽略
+	UpdateAttrs(index, id string, attrs map[string]float64, popularity float64) error
+	// 使用全部文档重建索引
+	Rebuild(index string, docs []Document) error
+	// 搜索
+	Search(query Query) (Result, error)
+}
+
+type Document struct {
+	ID string
+	// 全文检索字段
+	Fields map[string]string
+	// 数值属性，用于过滤
+	Attrs map[string]float64
+	// 发布时间，用于时间衰减
+	Time time.Time
+	// 热度，如播放量
+	Popularity float64
+}
+
+type Query struct {
+	Index    string
+	Keywords string
+	// 检索的字段和权重，为空时检索全部字段
+	Fields map[string]float64
+	// 文档至少需要匹配的查询词比例，为0时查询词不超过2个需要全部匹配，否则需要匹配75%
+	MinimumMatch float64
+	// 属性需要等于指定值
+	Filters map[string]float64
+	// 属性需要在区间内
+	Ranges []Range
+	// 按属性值从大到小排序，为空时按相关度排序
+	Sort string
+	// 需要统计数量的属性
+	Facets []Facet
+	// 需要高亮的字段
+	Highlight []string
+	// 新发布内容的加权
+	RecencyWeight float64
+	// 热度的加权
+	PopularityWeight float64
+	Offset           int
+	// 返回数量，不大于0时不返回结果
+	Limit int
+}
+
+// 属性区间 [Min, Max)，Max为0时没有上限
+type Range struct {
+	Attr string
+	Min  float64
+	Max  float64
+}
+
+// 分面统计，统计时不使用该属性自身的过滤条件
+type Facet struct {
+	Attr string
+	// 按区间统计，为空时按属性值统计
+	Ranges []Range
+}
+
+type FacetCount struct {
+	// 属性值，按区间统计时为区间序号
+	Value float64
+	Count int
+}
+
+type Result struct {
+	// 匹配的文档总数
+	Total int
+	Hits  []Hit
+	// 属性 -> 各个值的数量
+	Facets map[string][]FacetCount
+}
+
+type Hit struct {
+	Document
+	Score float64
+	// 高亮后的字段，匹配的词使用<em>标签包裹
+	Highlights map[string]string
+}
+
+var (
+	mu            sync.RWMutex
+	currentEngine Engine = NewMemory()
+)
+
+// 设置当前搜索引擎
+func SetEngine(engine Engine) {
+	mu.Lock()
+	currentEngine = engine
+	mu.Unlock()
+}
+
+// 获取当前搜索引擎
+func GetEngine() Engine {
+	mu.RLock()
+	defer mu.RUnlock()
+	return currentEngine
+}
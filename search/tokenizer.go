@@ -0,0 +1,101 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// 中日韩文字没有空格分词，使用单字和二元组索引
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// 全角字母数字转为半角
+func normalize(r rune) rune {
+	if r >= 0xFF01 && r <= 0xFF5E {
+		r -= 0xFEE0
+	}
+	return unicode.ToLower(r)
+}
+
+// 规范化文本，用于搜索词统计和前缀匹配，全角转半角、转小写并合并连续空白
+func Normalize(text string) string {
+	return strings.Join(strings.Fields(strings.Map(normalize, text)), " ")
+}
+
+// 将文本分为单词和中日韩文字片段
+func segments(text string) (words []string, cjk [][]rune) {
+	var word, run []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+		if len(run) > 0 {
+			cjk = append(cjk, run)
+			run = nil
+		}
+	}
+
+	for _, r := range text {
+		r = normalize(r)
+		switch {
+		case isCJK(r):
+			if len(word) > 0 {
+				flush()
+			}
+			run = append(run, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if len(run) > 0 {
+				flush()
+			}
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return
+}
+
+// 索引分词，中日韩文字同时生成单字和二元组
+func Tokenize(text string) []string {
+	words, cjk := segments(text)
+	tokens := words
+	for _, run := range cjk {
+		for i := range run {
+			tokens = append(tokens, string(run[i]))
+			if i+1 < len(run) {
+				tokens = append(tokens, string(run[i:i+2]))
+			}
+		}
+	}
+	return tokens
+}
+
+// 查询分词，中日韩文字只使用二元组，单个字时使用单字
+func QueryTerms(text string) []string {
+	words, cjk := segments(text)
+	seen := make(map[string]bool)
+	terms := make([]string, 0)
+	add := func(term string) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+
+	for _, word := range words {
+		add(word)
+	}
+	for _, run := range cjk {
+		if len(run) == 1 {
+			add(string(run))
+			continue
+		}
+		for i := 0; i+1 < len(run); i++ {
+			add(string(run[i : i+2]))
+		}
+	}
+	return terms
+}
@@ -22,5 +22,6 @@ func AddVideoClicks(videoId uint, ip string) {
 	if cache.GetClicksLimit(videoId, ip) == "" {
 		cache.AddClicks(videoId)
 		cache.SetClicksLimit(videoId, ip)
+		cache.AddSearchStats(videoId)
 	}
 }
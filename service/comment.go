@@ -12,6 +12,9 @@ import (
 // 插入评论
 func InsertComment(comment model.Comment) (primitive.ObjectID, error) {
 	_, err := mongoClient.Comment().InsertOne(context.TODO(), comment)
+	if err == nil {
+		IndexComment(comment)
+	}
 	return comment.ID, err
 }
 
@@ -22,6 +25,9 @@ func InsertReply(commentId primitive.ObjectID, reply model.Reply) (primitive.Obj
 			"reply": reply,
 		},
 	})
+	if err == nil {
+		IndexReply(commentId, reply)
+	}
 
 	return reply.ID, err
 }
@@ -230,6 +236,9 @@ func DeleteComment(objectId primitive.ObjectID) error {
 			"is_delete": true,
 		},
 	})
+	if err == nil {
+		DeleteCommentIndex(objectId)
+	}
 
 	return err
 }
@@ -245,6 +254,9 @@ func DeleteReply(commentId, replyId primitive.ObjectID) error {
 			"reply.$.is_delete": true,
 		},
 	})
+	if err == nil {
+		DeleteReplyIndex(commentId, replyId)
+	}
 
 	return err
 }
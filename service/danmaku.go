@@ -1,6 +1,15 @@
 package service
 
-import "clicli/domain/model"
+import (
+	"time"
+
+	"clicli/cache"
+	"clicli/domain/model"
+	"github.com/spf13/viper"
+)
+
+// 默认每个统计窗口内允许发送的弹幕数量
+const DEFAULT_DANMAKU_RATE_LIMIT = 5
 
 func InsertDanmaku(danmaku model.Danmaku) error {
 	return mysqlClient.Create(&danmaku).Error
@@ -10,3 +19,110 @@ func SelectDanmakuByVidAndPart(videoId, part int) (danmaku []model.Danmaku) {
 	mysqlClient.Where("vid = ? and part = ?", videoId, part).Order("time").Find(&danmaku)
 	return
 }
+
+// 检查用户发送弹幕的频率
+func AllowSendDanmaku(userId uint) bool {
+	limit := viper.GetInt64("danmaku.rate_limit")
+	if limit <= 0 {
+		limit = DEFAULT_DANMAKU_RATE_LIMIT
+	}
+	return cache.IncrDanmakuRate(userId) <= limit
+}
+
+func SelectDanmakuByID(id uint) (danmaku model.Danmaku) {
+	mysqlClient.First(&danmaku, id)
+	return
+}
+
+// 管理员搜索弹幕，vid、uid为0时不作为条件
+func AdminSelectDanmakuList(vid, uid uint, keywords string, page, pageSize int) (total int64, danmaku []model.Danmaku) {
+	search := mysqlClient.Model(&model.Danmaku{})
+	if vid != 0 {
+		search = search.Where("vid = ?", vid)
+	}
+	if uid != 0 {
+		search = search.Where("uid = ?", uid)
+	}
+	if keywords != "" {
+		search = search.Where("text like ?", "%"+keywords+"%")
+	}
+
+	search.Count(&total)
+	search.Order("id desc").Limit(pageSize).Offset((page - 1) * pageSize).Find(&danmaku)
+	return
+}
+
+// 批量删除弹幕和相关举报
+func DeleteDanmaku(ids []uint) error {
+	if err := mysqlClient.Where("did in ?", ids).Delete(&model.DanmakuReport{}).Error; err != nil {
+		return err
+	}
+	return mysqlClient.Delete(&model.Danmaku{}, ids).Error
+}
+
+// 举报弹幕，重复举报时更新原因
+func InsertDanmakuReport(report model.DanmakuReport) error {
+	var old model.DanmakuReport
+	mysqlClient.Unscoped().Where("did = ? and uid = ?", report.Did, report.Uid).First(&old)
+	if old.ID != 0 {
+		return mysqlClient.Unscoped().Model(&old).Updates(map[string]interface{}{
+			"reason":     report.Reason,
+			"deleted_at": nil,
+		}).Error
+	}
+	return mysqlClient.Create(&report).Error
+}
+
+// 按举报次数获取被举报的弹幕
+func SelectReportedDanmaku(page, pageSize int) (total int64, danmaku []model.Danmaku, reports map[uint]int64) {
+	var counts []struct {
+		Did   uint
+		Count int64
+	}
+
+	query := mysqlClient.Model(&model.DanmakuReport{}).Select("did, count(*) as count").Group("did")
+	mysqlClient.Table("(?) as r", query).Count(&total)
+	query.Order("count desc").Limit(pageSize).Offset((page - 1) * pageSize).Scan(&counts)
+
+	ids := make([]uint, len(counts))
+	reports = make(map[uint]int64, len(counts))
+	for i, c := range counts {
+		ids[i] = c.Did
+		reports[c.Did] = c.Count
+	}
+
+	danmakuMap := make(map[uint]model.Danmaku, len(ids))
+	var list []model.Danmaku
+	if len(ids) > 0 {
+		mysqlClient.Where("id in ?", ids).Find(&list)
+	}
+	for _, d := range list {
+		danmakuMap[d.ID] = d
+	}
+
+	// 保持举报次数的顺序
+	for _, id := range ids {
+		if d, ok := danmakuMap[id]; ok {
+			danmaku = append(danmaku, d)
+		}
+	}
+	return
+}
+
+// 忽略弹幕的举报
+func DeleteDanmakuReport(did uint) {
+	mysqlClient.Where("did = ?", did).Delete(&model.DanmakuReport{})
+}
+
+// 禁止用户发送弹幕，hours为0时解除
+func MuteDanmakuUser(userId uint, hours int) {
+	if hours <= 0 {
+		cache.DelDanmakuMute(userId)
+		return
+	}
+	cache.SetDanmakuMute(userId, time.Hour*time.Duration(hours))
+}
+
+func IsDanmakuMuted(userId uint) bool {
+	return cache.IsDanmakuMuted(userId)
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"io"
+	"unicode/utf8"
+
+	"clicli/domain/model"
+	"clicli/util/convert"
+	"clicli/util/danmaku"
+)
+
+// 单次导入的最大弹幕数量
+const MAX_IMPORT_DANMAKU = 10000
+
+// 导入弹幕文件的最大大小(MB)
+const MAX_DANMAKU_FILE_SIZE = 10
+
+// 导出弹幕为XML
+func ExportDanmakuXML(w io.Writer, videoId, part uint) error {
+	return danmaku.WriteXML(w, "clicli-"+convert.UintToString(videoId)+"-"+convert.UintToString(part), selectDanmakuItems(videoId, part))
+}
+
+// 导出弹幕为ASS字幕
+func ExportDanmakuASS(w io.Writer, videoId, part uint) error {
+	return danmaku.WriteASS(w, selectDanmakuItems(videoId, part))
+}
+
+// 导入XML弹幕，发送者记为导入的用户，跳过过长和包含违禁词的弹幕
+func ImportDanmakuXML(r io.Reader, videoId, part, userId uint) (int, error) {
+	items, err := danmaku.ReadXML(r)
+	if err != nil {
+		return 0, err
+	}
+
+	rows := make([]model.Danmaku, 0, len(items))
+	for _, item := range items {
+		if len(rows) >= MAX_IMPORT_DANMAKU {
+			break
+		}
+
+		length := utf8.RuneCountInString(item.Text)
+		if length == 0 || length > 100 || ContainsFilterWord(item.Text) {
+			continue
+		}
+
+		rows = append(rows, model.Danmaku{
+			Vid:   videoId,
+			Part:  part,
+			Time:  uint(item.Time),
+			Type:  item.Type,
+			Color: item.Color,
+			Text:  item.Text,
+			Uid:   userId,
+		})
+	}
+
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	if err := mysqlClient.CreateInBatches(&rows, 500).Error; err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+func selectDanmakuItems(videoId, part uint) []danmaku.Item {
+	rows := SelectDanmakuByVidAndPart(int(videoId), int(part))
+	items := make([]danmaku.Item, len(rows))
+	for i, row := range rows {
+		items[i] = danmaku.Item{
+			Time:  float64(row.Time),
+			Type:  row.Type,
+			Color: row.Color,
+			Text:  row.Text,
+		}
+	}
+	return items
+}
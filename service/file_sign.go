@@ -0,0 +1,145 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"clicli/common"
+	"clicli/domain/model"
+	"github.com/spf13/viper"
+)
+
+// 签名链接前缀
+const SIGNED_URL_PREFIX = "/api/file/"
+
+// 签名链接默认有效期 n 小时
+const DEFAULT_URL_EXPIRATION = 6
+
+// 审核员角色编号，审核员及以上可以访问未审核的资源
+const AUDITOR_ROLE = 1
+
+// 视频目录，目录中的文件使用同一个签名
+const SIGNED_DIR_PREFIX = "video/"
+
+// 视频目录中可以通过签名链接访问的文件类型，原始视频和转码中间文件不能访问
+var signedDirExts = map[string]bool{".mpd": true, ".m3u8": true, ".m4s": true, ".vtt": true, ".jpg": true}
+
+// 播放列表和预览图索引，文件中使用相对路径
+var playlistExts = map[string]bool{".mpd": true, ".m3u8": true, ".vtt": true}
+
+/**
+ * 生成带签名和有效期的文件链接
+ * 视频目录中的文件签名覆盖所在目录，DASH、HLS和预览图中的相对路径可以使用同一个签名
+ * 字幕等单个文件只对该文件签名
+ * 格式: /api/file/<过期时间>/<签名>/<对象key>
+ */
+func SignFileUrl(objectKey string) string {
+	expiration := viper.GetInt("security.url_expiration")
+	if expiration <= 0 {
+		expiration = DEFAULT_URL_EXPIRATION
+	}
+
+	// 过期时间取整到小时，同一时间段内链接不变便于缓存
+	expires := time.Now().Add(time.Hour * time.Duration(expiration)).Truncate(time.Hour).Add(time.Hour).Unix()
+	key := strings.TrimPrefix(path.Clean("/"+objectKey), "/")
+	return SIGNED_URL_PREFIX + strconv.FormatInt(expires, 10) + "/" + fileSignature(key, expires) + "/" + key
+}
+
+// 校验签名链接，返回规范化的对象key
+func VerifyFileSignature(expiresStr, signature, objectKey string) (string, bool) {
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return "", false
+	}
+
+	key := strings.TrimPrefix(path.Clean("/"+objectKey), "/")
+	if strings.HasPrefix(key, SIGNED_DIR_PREFIX) && !signedDirExts[path.Ext(key)] {
+		return "", false
+	}
+
+	expected := fileSignature(key, expires)
+	return key, hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// 视频目录中的播放列表，其中的相对路径需要继续使用签名链接，不能跳转到存储的预签名链接
+func IsPlaylistFile(objectKey string) bool {
+	return strings.HasPrefix(objectKey, SIGNED_DIR_PREFIX) && playlistExts[path.Ext(objectKey)]
+}
+
+// 对签名范围和过期时间签名
+func fileSignature(objectKey string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(viper.GetString("security.url_secret")))
+	mac.Write([]byte(signatureScope(objectKey) + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// 视频目录中的文件使用所在目录作为签名范围，其他文件使用完整的对象key
+func signatureScope(objectKey string) string {
+	if strings.HasPrefix(objectKey, SIGNED_DIR_PREFIX) {
+		return "dir:" + path.Dir(objectKey)
+	}
+	return "file:" + objectKey
+}
+
+/**
+ * 为资源生成签名的播放链接
+ * 未审核通过的资源只对上传者和审核员签名，其他用户返回空链接
+ */
+func SignResources(resources []model.Resource, userId uint) []model.Resource {
+	signed := make([]model.Resource, len(resources))
+	role := -1
+	for i, resource := range resources {
+		signed[i] = resource
+		if resource.Status != common.AUDIT_APPROVED && resource.Uid != userId {
+			if role < 0 {
+				role = 0
+				if userId != 0 {
+					role = SelectUserByID(userId).Role
+				}
+			}
+
+			if role < AUDITOR_ROLE {
+				signed[i].Url, signed[i].HlsUrl, signed[i].Storyboard, signed[i].Covers = "", "", "", ""
+				signed[i].Subtitles = nil
+				continue
+			}
+		}
+
+		signed[i].Url = signResourceUrl(resource, resource.Url)
+		signed[i].HlsUrl = signResourceUrl(resource, resource.HlsUrl)
+		signed[i].Storyboard = signResourceUrl(resource, resource.Storyboard)
+		if resource.Covers != "" {
+			covers := strings.Split(resource.Covers, ",")
+			for j := range covers {
+				covers[j] = signResourceUrl(resource, covers[j])
+			}
+			signed[i].Covers = strings.Join(covers, ",")
+		}
+
+		signed[i].Subtitles = make([]model.Subtitle, len(resource.Subtitles))
+		for j, subtitle := range resource.Subtitles {
+			signed[i].Subtitles[j] = subtitle
+			signed[i].Subtitles[j].Url = SignSubtitleUrl(subtitle.Url)
+		}
+	}
+
+	return signed
+}
+
+// 资源文件都在视频目录中，通过文件名得到对象key
+func signResourceUrl(resource model.Resource, url string) string {
+	if url == "" {
+		return ""
+	}
+	return SignFileUrl("video/" + GetResourceDir(resource) + "/" + path.Base(url))
+}
+
+// 字幕文件在单独的目录中
+func SignSubtitleUrl(url string) string {
+	return SignFileUrl("subtitle/" + path.Base(url))
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"clicli/cache"
+	"clicli/domain/model"
+	"go.uber.org/zap"
+)
+
+// 已编译的违禁词，版本与缓存中不一致时重新加载
+var filterWords struct {
+	mux     sync.RWMutex
+	loaded  bool
+	version string
+	words   []string
+	regexps []*regexp.Regexp
+}
+
+func InsertFilterWord(word model.FilterWord) error {
+	if err := mysqlClient.Create(&word).Error; err != nil {
+		return err
+	}
+
+	cache.IncrFilterWordVersion()
+	return nil
+}
+
+func DeleteFilterWord(id uint) {
+	mysqlClient.Delete(&model.FilterWord{}, id)
+	cache.IncrFilterWordVersion()
+}
+
+func SelectFilterWordList(page, pageSize int) (total int64, words []model.FilterWord) {
+	mysqlClient.Model(&model.FilterWord{}).Count(&total)
+	mysqlClient.Order("id desc").Limit(pageSize).Offset((page - 1) * pageSize).Find(&words)
+	return
+}
+
+// 编译正则表达式，忽略大小写
+func CompileFilterRegex(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// 内容是否包含违禁词
+func ContainsFilterWord(text string) bool {
+	loadFilterWords()
+
+	filterWords.mux.RLock()
+	defer filterWords.mux.RUnlock()
+
+	lower := strings.ToLower(text)
+	for _, word := range filterWords.words {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+
+	for _, re := range filterWords.regexps {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func loadFilterWords() {
+	version := cache.GetFilterWordVersion()
+
+	filterWords.mux.RLock()
+	fresh := filterWords.loaded && filterWords.version == version
+	filterWords.mux.RUnlock()
+	if fresh {
+		return
+	}
+
+	var list []model.FilterWord
+	mysqlClient.Find(&list)
+
+	words := make([]string, 0, len(list))
+	regexps := make([]*regexp.Regexp, 0)
+	for _, item := range list {
+		if !item.Regex {
+			words = append(words, strings.ToLower(item.Word))
+			continue
+		}
+
+		re, err := CompileFilterRegex(item.Word)
+		if err != nil {
+			zap.L().Error("违禁词正则表达式有误:" + item.Word)
+			continue
+		}
+		regexps = append(regexps, re)
+	}
+
+	filterWords.mux.Lock()
+	filterWords.loaded = true
+	filterWords.version = version
+	filterWords.words = words
+	filterWords.regexps = regexps
+	filterWords.mux.Unlock()
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"image"
+
+	"clicli/domain/valid"
+	"clicli/storage"
+	"github.com/disintegration/imaging"
+	"github.com/spf13/viper"
+)
+
+// 图片默认最大边长(像素)
+const DEFAULT_MAX_IMG_DIMENSION = 4096
+
+// 重新编码时的jpeg质量
+const IMG_JPEG_QUALITY = 90
+
+var (
+	ErrImageDecode    = errors.New("image decode failed")
+	ErrImageDimension = errors.New("image dimension exceeds limit")
+)
+
+// 保存上传的图片，重新编码以去除EXIF/GPS等元数据并按方向信息旋转
+func SaveUploadImage(data []byte, fileName string) error {
+	// 解码前先检查尺寸，避免解码超大图片
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ErrImageDecode
+	}
+
+	if !valid.MediaDimension(config.Width, config.Height, GetMaxImageDimension()) {
+		return ErrImageDimension
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return ErrImageDecode
+	}
+
+	// 按文件后缀选择编码格式
+	format, err := imaging.FormatFromFilename(fileName)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, format, imaging.JPEGQuality(IMG_JPEG_QUALITY)); err != nil {
+		return err
+	}
+
+	return storage.GetStorage().PutObject("image/"+fileName, &buf)
+}
+
+// 获取图片最大边长
+func GetMaxImageDimension() int {
+	if dimension := viper.GetInt("file.max_img_dimension"); dimension > 0 {
+		return dimension
+	}
+	return DEFAULT_MAX_IMG_DIMENSION
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"clicli/cache"
 	"clicli/domain/model"
 	"go.mongodb.org/mongo-driver/bson"
 )
@@ -24,6 +25,10 @@ func Like(videoId, userId uint) error {
 			"user_ids": userId,
 		},
 	})
+	if err == nil {
+		// 定时更新搜索索引中的点赞数
+		cache.AddSearchStats(videoId)
+	}
 
 	return err
 }
@@ -35,6 +40,9 @@ func CancelLike(videoId, userId uint) error {
 			"user_ids": userId,
 		},
 	})
+	if err == nil {
+		cache.AddSearchStats(videoId)
+	}
 
 	return err
 }
@@ -91,3 +99,28 @@ func SelectLikeCount(videoId uint) (int32, error) {
 
 	return 0, errors.New("点赞数获取失败")
 }
+
+// 批量获取点赞数
+func SelectLikeCounts(videoIds []uint) (map[uint]int32, error) {
+	cursor, err := mongoClient.Like().Aggregate(context.TODO(), bson.A{
+		bson.M{"$match": bson.M{"vid": bson.M{"$in": videoIds}}},
+		bson.M{"$project": bson.M{"_id": 0, "vid": 1, "count": bson.M{"$size": "$user_ids"}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var likes []struct {
+		Vid   uint  `bson:"vid"`
+		Count int32 `bson:"count"`
+	}
+	if err := cursor.All(context.TODO(), &likes); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int32, len(likes))
+	for _, like := range likes {
+		counts[like.Vid] = like.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"clicli/cache"
+	"clicli/domain/dto"
+	"clicli/domain/model"
+	"clicli/util/authentication"
+	"clicli/util/random"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 默认直播应用名
+const DEFAULT_LIVE_APP = "live"
+
+// 推流名称长度(随机字节数)
+const LIVE_STREAM_NAME_LENGTH = 16
+
+// 推流密钥长度(随机字节数)
+const LIVE_STREAM_KEY_LENGTH = 24
+
+// 推流权限，在casbin中配置允许推流的角色
+const (
+	LIVE_PUBLISH_OBJECT = "/api/v1/live/callback/publish"
+	LIVE_PUBLISH_ACTION = "PUBLISH"
+)
+
+var (
+	ErrLiveRoomNotExist   = errors.New("live room not exist")
+	ErrStreamKeyInvalid   = errors.New("stream key invalid")
+	ErrLivePublishBlocked = errors.New("user not allowed to publish")
+)
+
+// 获取用户的直播间，不存在时创建
+func GetOrCreateLiveRoom(userId uint) (model.LiveRoom, error) {
+	var room model.LiveRoom
+	mysqlClient.Where("uid = ?", userId).First(&room)
+	if room.ID != 0 {
+		return room, nil
+	}
+
+	room = model.LiveRoom{
+		Uid:        userId,
+		Title:      GetUserInfo(userId).Username + "的直播间",
+		StreamName: random.GenerateSecretKey(LIVE_STREAM_NAME_LENGTH),
+		StreamKey:  random.GenerateSecretKey(LIVE_STREAM_KEY_LENGTH),
+	}
+	if err := mysqlClient.Create(&room).Error; err != nil {
+		return room, err
+	}
+	return room, nil
+}
+
+// 通过ID获取直播间
+func SelectLiveRoomByID(id uint) (room model.LiveRoom) {
+	mysqlClient.First(&room, id)
+	return
+}
+
+// 通过推流名称获取直播间
+func SelectLiveRoomByStream(streamName string) (room model.LiveRoom) {
+	mysqlClient.Where("stream_name = ?", streamName).First(&room)
+	return
+}
+
+// 通过ID列表获取直播间，保持ID的顺序
+func SelectLiveRoomByIds(ids []uint) []model.LiveRoom {
+	var rooms []model.LiveRoom
+	if len(ids) == 0 {
+		return rooms
+	}
+	mysqlClient.Where("id in ?", ids).Find(&rooms)
+
+	roomMap := make(map[uint]model.LiveRoom, len(rooms))
+	for _, room := range rooms {
+		roomMap[room.ID] = room
+	}
+
+	ordered := make([]model.LiveRoom, 0, len(rooms))
+	for _, id := range ids {
+		if room, ok := roomMap[id]; ok {
+			ordered = append(ordered, room)
+		}
+	}
+	return ordered
+}
+
+// 修改直播间信息
+func ModifyLiveRoom(userId uint, modifyDTO dto.ModifyLiveRoomDTO) error {
+	return mysqlClient.Model(&model.LiveRoom{}).Where("uid = ?", userId).Updates(map[string]interface{}{
+		"title": modifyDTO.Title,
+		"cover": modifyDTO.Cover,
+	}).Error
+}
+
+// 重新生成推流密钥，正在进行的推流会被断开
+func RotateStreamKey(userId uint) (model.LiveRoom, error) {
+	return updateStreamKey(userId, random.GenerateSecretKey(LIVE_STREAM_KEY_LENGTH))
+}
+
+// 吊销推流密钥，重新生成前无法推流
+func RevokeStreamKey(userId uint) (model.LiveRoom, error) {
+	return updateStreamKey(userId, "")
+}
+
+func updateStreamKey(userId uint, streamKey string) (model.LiveRoom, error) {
+	room, err := GetOrCreateLiveRoom(userId)
+	if err != nil {
+		return room, err
+	}
+
+	if err := mysqlClient.Model(&room).Update("stream_key", streamKey).Error; err != nil {
+		return room, err
+	}
+
+	// 使用旧密钥的推流立即断开
+	kickLiveClient(room.ID)
+	return room, nil
+}
+
+// 分页获取在线直播间
+func SelectOnlineLiveRoom(page, pageSize int) (int64, []model.LiveRoom) {
+	total, ids := cache.GetOnlineLive(page, pageSize)
+	rooms := SelectLiveRoomByIds(ids)
+	for i := range rooms {
+		rooms[i].Author = GetUserInfo(rooms[i].Uid)
+	}
+	return total, rooms
+}
+
+// 开始推流
+func LivePublish(callbackDTO dto.SrsCallbackDTO) (model.LiveRoom, error) {
+	room := SelectLiveRoomByStream(callbackDTO.Stream)
+	if room.ID == 0 || callbackDTO.App != GetLiveApp() {
+		return room, ErrLiveRoomNotExist
+	}
+
+	// 校验推流密钥
+	key := getStreamKeyParam(callbackDTO.Param)
+	if room.StreamKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(room.StreamKey)) != 1 {
+		return room, ErrStreamKeyInvalid
+	}
+
+	// 校验用户状态和推流权限
+	user := SelectUserByID(room.Uid)
+	if user.ID == 0 || user.Status != "0" ||
+		!authentication.Check(dto.GetRoleString(user.Role), LIVE_PUBLISH_OBJECT, LIVE_PUBLISH_ACTION) {
+		return room, ErrLivePublishBlocked
+	}
+
+	cache.SetLiveOnline(room.ID, callbackDTO.ClientId)
+	StartLiveRecord(room, callbackDTO.ClientId)
+	zap.L().Info("直播间" + room.StreamName + "开始推流")
+	return room, nil
+}
+
+// 停止推流
+func LiveUnpublish(callbackDTO dto.SrsCallbackDTO) (model.LiveRoom, error) {
+	room := SelectLiveRoomByStream(callbackDTO.Stream)
+	if room.ID == 0 {
+		return room, ErrLiveRoomNotExist
+	}
+
+	// 重新推流后，旧连接的停止回调不影响在线状态
+	if client := cache.GetLiveClient(room.ID); client != "" && client != callbackDTO.ClientId {
+		return room, nil
+	}
+
+	cache.DelLiveOnline(room.ID)
+	StopLiveRecord(room.ID)
+	zap.L().Info("直播间" + room.StreamName + "停止推流")
+	return room, nil
+}
+
+// DVR录制完成
+func LiveDvr(callbackDTO dto.SrsCallbackDTO) error {
+	room := SelectLiveRoomByStream(callbackDTO.Stream)
+	if room.ID == 0 || callbackDTO.App != GetLiveApp() {
+		return ErrLiveRoomNotExist
+	}
+
+	return FinishDvrRecord(room, callbackDTO.ClientId, callbackDTO.Cwd, callbackDTO.File)
+}
+
+// 发送直播弹幕，时间为相对开播的秒数
+func InsertLiveDanmaku(danmaku model.LiveDanmaku) (model.LiveDanmaku, error) {
+	startTime := cache.GetLiveStartTime(danmaku.RoomId)
+	if startTime == 0 {
+		return danmaku, ErrLiveRoomNotExist
+	}
+
+	if now := time.Now().Unix(); now > startTime {
+		danmaku.Time = uint(now - startTime)
+	}
+	danmaku.RecordId = SelectRecordingId(danmaku.RoomId)
+	err := mysqlClient.Create(&danmaku).Error
+	return danmaku, err
+}
+
+// 直播应用名
+func GetLiveApp() string {
+	if app := viper.GetString("live.app"); app != "" {
+		return app
+	}
+	return DEFAULT_LIVE_APP
+}
+
+// 推流地址，密钥以参数形式附加
+func GetLivePushUrl(streamName, streamKey string) string {
+	if streamKey == "" {
+		return ""
+	}
+	return viper.GetString("live.push_url") + "/" + streamName + "?key=" + streamKey
+}
+
+// 从回调参数(?key=xxx&...)中读取推流密钥
+func getStreamKeyParam(param string) string {
+	values, err := url.ParseQuery(strings.TrimPrefix(param, "?"))
+	if err != nil {
+		return ""
+	}
+	return values.Get("key")
+}
+
+// 通过媒体服务器API断开直播间的推流客户端
+func kickLiveClient(roomId uint) {
+	clientId := cache.GetLiveClient(roomId)
+	apiUrl := viper.GetString("live.api_url")
+	if clientId == "" || apiUrl == "" {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, apiUrl+"/api/v1/clients/"+url.PathEscape(clientId), nil)
+	if err != nil {
+		zap.L().Error("断开推流客户端失败" + err.Error())
+		return
+	}
+
+	client := http.Client{Timeout: 10 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		zap.L().Error("断开推流客户端失败" + err.Error())
+		return
+	}
+	res.Body.Close()
+}
+
+// 播放地址
+func GetLivePlayUrl(streamName string) (flvUrl, hlsUrl string) {
+	if base := viper.GetString("live.flv_url"); base != "" {
+		flvUrl = base + "/" + streamName + ".flv"
+	}
+	if base := viper.GetString("live.hls_url"); base != "" {
+		hlsUrl = base + "/" + streamName + ".m3u8"
+	}
+	return
+}
+
+type srsStreams struct {
+	Streams []struct {
+		Name    string `json:"name"`
+		App     string `json:"app"`
+		Publish struct {
+			Active bool `json:"active"`
+		} `json:"publish"`
+	} `json:"streams"`
+}
+
+// 与媒体服务器同步在线状态，清理媒体服务器异常退出时遗留的在线记录
+func SyncLiveOnlineState() {
+	apiUrl := viper.GetString("live.api_url")
+	if apiUrl == "" {
+		return
+	}
+
+	client := http.Client{Timeout: 10 * time.Second}
+	res, err := client.Get(apiUrl + "/api/v1/streams?count=10000")
+	if err != nil {
+		zap.L().Error("获取媒体服务器推流列表失败" + err.Error())
+		return
+	}
+	defer res.Body.Close()
+
+	var streams srsStreams
+	if err := json.NewDecoder(res.Body).Decode(&streams); err != nil {
+		zap.L().Error("解析媒体服务器推流列表失败" + err.Error())
+		return
+	}
+
+	active := make(map[string]bool)
+	for _, s := range streams.Streams {
+		if s.App == GetLiveApp() && s.Publish.Active {
+			active[s.Name] = true
+		}
+	}
+
+	for _, room := range SelectLiveRoomByIds(cache.GetAllOnlineLive()) {
+		if !active[room.StreamName] {
+			cache.DelLiveOnline(room.ID)
+			StopLiveRecord(room.ID)
+			zap.L().Info("直播间" + room.StreamName + "已不在推流，清除在线状态")
+		}
+	}
+}
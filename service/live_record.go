@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"clicli/common"
+	"clicli/domain/model"
+	"clicli/util/transcoding"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 录像方式
+const (
+	// 服务端拉取直播流录制
+	LIVE_RECORD_PULL = "pull"
+	// 媒体服务器DVR录制，通过on_dvr回调通知
+	LIVE_RECORD_DVR = "dvr"
+)
+
+// 录像文件名，录制完成后转为upload.mp4进入转码流程
+const LIVE_RECORD_FILE = "live.flv"
+
+// 开播后等待媒体服务器可以播放再开始拉流(秒)
+const LIVE_RECORD_DELAY = 3
+
+// 拉流失败的最大重试次数
+const LIVE_RECORD_RETRY = 3
+
+var ErrLiveRecordFile = errors.New("invalid live record file")
+
+type liveRecorder struct {
+	recordId uint
+	cancel   context.CancelFunc
+}
+
+var (
+	liveRecorders   = make(map[uint]liveRecorder) // 直播间ID -> 拉流录制
+	liveRecorderMux sync.Mutex
+)
+
+// 录像方式，未配置时不录制
+func GetLiveRecordMode() string {
+	return viper.GetString("live.record")
+}
+
+// 启动时处理服务重启前未完成的录像
+func InitLiveRecord() {
+	var records []model.LiveRecord
+	mysqlClient.Where("status = ?", common.LIVE_RECORDING).Find(&records)
+	for _, record := range records {
+		recordFile := "./upload/video/" + record.Dir + "/" + LIVE_RECORD_FILE
+		if _, err := os.Stat(recordFile); err == nil {
+			// 拉流录制已中断，保存已录制的部分
+			go archiveLiveRecord(record, recordFile)
+		} else if GetLiveRecordMode() != LIVE_RECORD_DVR {
+			failLiveRecord(record, errors.New("录像文件不存在"))
+		}
+	}
+}
+
+// 开始录像
+func StartLiveRecord(room model.LiveRoom, clientId string) {
+	mode := GetLiveRecordMode()
+	if mode != LIVE_RECORD_PULL && mode != LIVE_RECORD_DVR {
+		return
+	}
+
+	dirName := GenerateVideoFilename()
+	if err := os.MkdirAll("./upload/video/"+dirName, os.ModePerm); err != nil {
+		zap.L().Error("创建录像文件夹失败" + err.Error())
+		return
+	}
+
+	record := model.LiveRecord{
+		RoomId:    room.ID,
+		Uid:       room.Uid,
+		Title:     room.Title,
+		ClientId:  clientId,
+		Dir:       dirName,
+		StartedAt: time.Now(),
+		Status:    common.LIVE_RECORDING,
+	}
+	if err := mysqlClient.Create(&record).Error; err != nil {
+		zap.L().Error("创建录像记录失败" + err.Error())
+		return
+	}
+
+	if mode == LIVE_RECORD_PULL {
+		startLiveRecorder(room, record)
+	}
+}
+
+// 停止录像，拉流录制结束后自动转为视频
+func StopLiveRecord(roomId uint) {
+	liveRecorderMux.Lock()
+	recorder, ok := liveRecorders[roomId]
+	delete(liveRecorders, roomId)
+	liveRecorderMux.Unlock()
+
+	if ok {
+		recorder.cancel()
+	}
+}
+
+// 媒体服务器DVR录制完成
+func FinishDvrRecord(room model.LiveRoom, clientId, cwd, file string) error {
+	if GetLiveRecordMode() != LIVE_RECORD_DVR {
+		return nil
+	}
+
+	// 相对路径基于媒体服务器的工作目录
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(cwd, file)
+	}
+
+	if !isDvrFile(file) {
+		return ErrLiveRecordFile
+	}
+
+	var record model.LiveRecord
+	mysqlClient.Where("room_id = ? and client_id = ? and status = ?", room.ID, clientId, common.LIVE_RECORDING).
+		Last(&record)
+	if record.ID == 0 {
+		return ErrLiveRecordFile
+	}
+
+	go archiveLiveRecord(record, file)
+	return nil
+}
+
+// 获取直播间当前的录像ID，没有录像返回0
+func SelectRecordingId(roomId uint) (id uint) {
+	mysqlClient.Model(&model.LiveRecord{}).Select("id").
+		Where("room_id = ? and status = ?", roomId, common.LIVE_RECORDING).Order("id desc").Limit(1).Scan(&id)
+	return
+}
+
+// DVR文件需要在配置的目录内
+func isDvrFile(file string) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	if ext != ".flv" && ext != ".mp4" {
+		return false
+	}
+
+	dvrDir := viper.GetString("live.dvr_dir")
+	if dvrDir == "" {
+		return false
+	}
+
+	rel, err := filepath.Rel(filepath.Clean(dvrDir), filepath.Clean(file))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func startLiveRecorder(room model.LiveRoom, record model.LiveRecord) {
+	streamUrl := viper.GetString("live.record_url")
+	if streamUrl == "" {
+		streamUrl = viper.GetString("live.flv_url")
+	}
+	streamUrl += "/" + room.StreamName + ".flv"
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// 重新推流时结束上一次的录像
+	liveRecorderMux.Lock()
+	if previous, ok := liveRecorders[room.ID]; ok {
+		previous.cancel()
+	}
+	liveRecorders[room.ID] = liveRecorder{recordId: record.ID, cancel: cancel}
+	liveRecorderMux.Unlock()
+
+	go func() {
+		defer removeLiveRecorder(room.ID, record.ID)
+
+		recordFile := "./upload/video/" + record.Dir + "/" + LIVE_RECORD_FILE
+		for i := 0; i < LIVE_RECORD_RETRY; i++ {
+			select {
+			case <-time.After(LIVE_RECORD_DELAY * time.Second):
+			case <-ctx.Done():
+			}
+
+			if ctx.Err() == nil {
+				// 弹幕时间以实际开始录制的时间为准
+				if i == 0 {
+					record.StartedAt = time.Now()
+					mysqlClient.Model(&record).Update("started_at", record.StartedAt)
+				}
+				if err := transcoding.RecordStream(ctx, streamUrl, recordFile); err != nil {
+					zap.L().Error("直播录制中断" + err.Error())
+				}
+			}
+
+			// 已经录制到内容或直播已结束
+			if info, err := os.Stat(recordFile); (err == nil && info.Size() > 0) || ctx.Err() != nil {
+				break
+			}
+		}
+
+		archiveLiveRecord(record, recordFile)
+	}()
+}
+
+func removeLiveRecorder(roomId, recordId uint) {
+	liveRecorderMux.Lock()
+	if recorder, ok := liveRecorders[roomId]; ok && recorder.recordId == recordId {
+		recorder.cancel()
+		delete(liveRecorders, roomId)
+	}
+	liveRecorderMux.Unlock()
+}
+
+// 将录像转为视频，进入转码和审核流程
+func archiveLiveRecord(record model.LiveRecord, recordFile string) {
+	localDir := "./upload/video/" + record.Dir + "/"
+	inputFile := localDir + "upload.mp4"
+
+	if _, err := os.Stat(recordFile); err != nil {
+		failLiveRecord(record, errors.New("录像文件不存在"))
+		return
+	}
+
+	if err := transcoding.RemuxVideo(recordFile, inputFile); err != nil {
+		failLiveRecord(record, err)
+		return
+	}
+	os.Remove(recordFile)
+
+	quality, duration, err := PreTreatmentVideo(inputFile)
+	if err != nil {
+		failLiveRecord(record, err)
+		return
+	}
+
+	room := SelectLiveRoomByID(record.RoomId)
+	video := model.Video{
+		Title:       record.Title,
+		Cover:       room.Cover,
+		Desc:        "直播录像 " + record.StartedAt.Format("2006/01/02 15:04"),
+		Uid:         record.Uid,
+		Copyright:   true,
+		Status:      common.SUBMIT_REVIEW, // 转码完成后进入待审核
+		PartitionId: viper.GetUint("live.record_partition"),
+	}
+	vid, err := InsertVideo(video)
+	if err != nil {
+		failLiveRecord(record, err)
+		return
+	}
+
+	if _, err := CreateVideoResource(vid, record.Uid, record.Dir, quality, duration); err != nil {
+		zap.L().Error("录像创建转码任务失败" + err.Error())
+	}
+
+	if err := copyLiveDanmaku(record, vid); err != nil {
+		zap.L().Error("保存直播弹幕失败" + err.Error())
+	}
+
+	mysqlClient.Model(&record).Updates(map[string]interface{}{
+		"vid":    vid,
+		"status": common.LIVE_RECORD_ARCHIVED,
+	})
+	zap.L().Info("直播录像已转为视频:" + record.Dir)
+}
+
+// 将直播期间的弹幕按录像开始的时间偏移保存为视频弹幕
+func copyLiveDanmaku(record model.LiveRecord, vid uint) error {
+	var liveDanmaku []model.LiveDanmaku
+	mysqlClient.Where("record_id = ?", record.ID).Find(&liveDanmaku)
+	if len(liveDanmaku) == 0 {
+		return nil
+	}
+
+	danmaku := make([]model.Danmaku, len(liveDanmaku))
+	for i, d := range liveDanmaku {
+		var offset uint
+		if d.CreatedAt.After(record.StartedAt) {
+			offset = uint(d.CreatedAt.Sub(record.StartedAt).Seconds())
+		}
+
+		danmaku[i] = model.Danmaku{
+			Vid:   vid,
+			Part:  1,
+			Time:  offset,
+			Type:  d.Type,
+			Color: d.Color,
+			Text:  d.Text,
+			Uid:   d.Uid,
+		}
+	}
+
+	return mysqlClient.CreateInBatches(&danmaku, 100).Error
+}
+
+func failLiveRecord(record model.LiveRecord, err error) {
+	zap.L().Error("直播录像处理失败:" + record.Dir + " " + err.Error())
+	mysqlClient.Model(&record).Update("status", common.LIVE_RECORD_FAILED)
+	os.RemoveAll("./upload/video/" + record.Dir)
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"clicli/cache"
+	"clicli/common"
+	"clicli/db/mysql"
+	"clicli/domain/model"
+	"clicli/recommend"
+	"github.com/go-redis/redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+// 观看、点赞和收藏的行为权重
+const (
+	RECOMMEND_HISTORY_WEIGHT = 1
+	RECOMMEND_LIKE_WEIGHT    = 2
+	RECOMMEND_COLLECT_WEIGHT = 3
+)
+
+// 参与计算的观看记录天数和每个用户最近观看的数量
+const (
+	RECOMMEND_HISTORY_DAYS  = 180
+	RECOMMEND_HISTORY_COUNT = 100
+)
+
+// 每个用户参与计算的行为数量(观看、点赞和收藏合并后)
+const RECOMMEND_INTERACTION_COUNT = 200
+
+// 每个视频保留的相似视频数量
+const RECOMMEND_NEIGHBOR_COUNT = 50
+
+// 每个用户缓存的推荐数量
+const RECOMMEND_USER_COUNT = 200
+
+// 缓存的热门视频数量
+const RECOMMEND_POPULAR_COUNT = 500
+
+// 热门视频的时间衰减半衰期(天)
+const RECOMMEND_POPULAR_HALF_LIFE = 30
+
+// 读取推荐时排除的最近观看数量
+const RECOMMEND_WATCHED_COUNT = 1000
+
+/**
+ * 重建推荐数据
+ * 根据观看记录、点赞和收藏计算视频相似度，为每个有行为的用户生成推荐视频
+ * 同时按播放量、点赞、收藏和发布时间计算热门视频
+ */
+func RebuildRecommend() {
+	start := time.Now()
+	zap.L().Info("开始计算推荐视频")
+
+	var videos []model.Video
+	mysqlClient.Scopes(mysql.ExcludeLegacyLive).Select("id", "clicks", "created_at").Where("status = ?", common.AUDIT_APPROVED).Find(&videos)
+	approved := make(map[uint]bool, len(videos))
+	for _, video := range videos {
+		approved[video.ID] = true
+	}
+
+	interactions, watched := selectHistoryInteractions(approved)
+	likes, err := addLikeInteractions(interactions, approved)
+	if err != nil {
+		zap.L().Error("获取点赞数据失败" + err.Error())
+	}
+	collects, err := addCollectInteractions(interactions, approved)
+	if err != nil {
+		zap.L().Error("获取收藏数据失败" + err.Error())
+	}
+
+	rebuildPopularRecommend(videos, likes, collects)
+
+	// 相似度计算的复杂度与每个用户行为数量的平方成正比
+	recommend.LimitInteractions(interactions, RECOMMEND_INTERACTION_COUNT)
+	similar := recommend.Similarity(interactions, RECOMMEND_NEIGHBOR_COUNT)
+	for userId, items := range interactions {
+		recommended := recommend.Recommend(items, similar, watched[userId], RECOMMEND_USER_COUNT)
+		members := make([]redis.Z, len(recommended))
+		for i, item := range recommended {
+			members[i] = redis.Z{Score: item.Score, Member: item.ID}
+		}
+		cache.SetUserRecommend(userId, members)
+	}
+
+	zap.L().Info("推荐视频计算完成，用户" + strconv.Itoa(len(interactions)) + "个，耗时 " + time.Since(start).String())
+}
+
+// 还没有热门视频数据时(如首次部署)在后台计算推荐数据，之后由定时任务更新
+func InitRecommend() {
+	if !cache.Exists(cache.RECOMMEND_POPULAR_KEY) {
+		go RebuildRecommend()
+	}
+}
+
+/**
+ * 获取推荐视频
+ * 登录用户优先使用预先计算的推荐结果，不足时使用热门视频补充，排除已观看的视频
+ * 未登录用户(userId为0)返回热门视频
+ */
+func SelectRecommendedVideo(userId uint, count int) []model.Video {
+	candidates := make([]uint, 0)
+	watched := make(map[uint]bool)
+	if userId != 0 {
+		candidates = append(candidates, cache.GetUserRecommend(userId, RECOMMEND_USER_COUNT)...)
+
+		var videoIds []uint
+		mysqlClient.Model(&model.History{}).Where("uid = ?", userId).Order("updated_at desc").
+			Limit(RECOMMEND_WATCHED_COUNT).Pluck("vid", &videoIds)
+		for _, id := range videoIds {
+			watched[id] = true
+		}
+	}
+	candidates = append(candidates, cache.GetPopularRecommend(RECOMMEND_POPULAR_COUNT)...)
+
+	ids := make([]uint, 0, count)
+	for _, id := range candidates {
+		if len(ids) == count {
+			break
+		}
+		if !watched[id] {
+			watched[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	// 还没有计算推荐数据时按播放量查询
+	if len(ids) == 0 {
+		return SelectVideoListByClicks(count)
+	}
+
+	// 计算后可能被删除或重新审核
+	videoMap := selectVideoMap(ids)
+	videos := make([]model.Video, 0, len(ids))
+	for _, id := range ids {
+		if video, ok := videoMap[id]; ok && video.Status == common.AUDIT_APPROVED {
+			videos = append(videos, video)
+		}
+	}
+	return videos
+}
+
+// 获取最近的观看记录，返回用户行为和已观看的视频
+func selectHistoryInteractions(approved map[uint]bool) (map[uint]map[uint]float64, map[uint]map[uint]bool) {
+	var histories []model.History
+	mysqlClient.Select("uid", "vid").Where("updated_at > ?", time.Now().AddDate(0, 0, -RECOMMEND_HISTORY_DAYS)).
+		Order("uid, updated_at desc").Find(&histories)
+
+	interactions := make(map[uint]map[uint]float64)
+	watched := make(map[uint]map[uint]bool)
+	for _, history := range histories {
+		if watched[history.Uid] == nil {
+			watched[history.Uid] = make(map[uint]bool)
+		}
+		watched[history.Uid][history.Vid] = true
+
+		if !approved[history.Vid] || len(interactions[history.Uid]) >= RECOMMEND_HISTORY_COUNT {
+			continue
+		}
+		addInteraction(interactions, history.Uid, history.Vid, RECOMMEND_HISTORY_WEIGHT)
+	}
+	return interactions, watched
+}
+
+// 添加点赞行为，返回每个视频的点赞数
+func addLikeInteractions(interactions map[uint]map[uint]float64, approved map[uint]bool) (map[uint]int, error) {
+	cursor, err := mongoClient.Like().Find(context.TODO(), bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	var likes []model.Like
+	if err := cursor.All(context.TODO(), &likes); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int, len(likes))
+	for _, like := range likes {
+		if !approved[like.Vid] {
+			continue
+		}
+		counts[like.Vid] = len(like.UserIds)
+		for _, userId := range like.UserIds {
+			addInteraction(interactions, userId, like.Vid, RECOMMEND_LIKE_WEIGHT)
+		}
+	}
+	return counts, nil
+}
+
+// 添加收藏行为，返回每个视频的收藏人数
+func addCollectInteractions(interactions map[uint]map[uint]float64, approved map[uint]bool) (map[uint]int, error) {
+	cursor, err := mongoClient.Collect().Find(context.TODO(), bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	var collects []model.Collect
+	if err := cursor.All(context.TODO(), &collects); err != nil {
+		return nil, err
+	}
+
+	// 同一个视频收藏到多个收藏夹只计算一次
+	collected := make(map[uint]map[uint]bool)
+	for _, collect := range collects {
+		for _, videoId := range collect.VideoIds {
+			if !approved[videoId] {
+				continue
+			}
+			if collected[collect.Uid] == nil {
+				collected[collect.Uid] = make(map[uint]bool)
+			}
+			collected[collect.Uid][videoId] = true
+		}
+	}
+
+	counts := make(map[uint]int)
+	for userId, videoIds := range collected {
+		for videoId := range videoIds {
+			counts[videoId]++
+			addInteraction(interactions, userId, videoId, RECOMMEND_COLLECT_WEIGHT)
+		}
+	}
+	return counts, nil
+}
+
+func addInteraction(interactions map[uint]map[uint]float64, userId, videoId uint, weight float64) {
+	if interactions[userId] == nil {
+		interactions[userId] = make(map[uint]float64)
+	}
+	interactions[userId][videoId] += weight
+}
+
+// 热度按播放量(每天同步到数据库)、点赞和收藏的对数计算，新发布的视频加权
+func rebuildPopularRecommend(videos []model.Video, likes, collects map[uint]int) {
+	items := make([]recommend.Item, len(videos))
+	for i, video := range videos {
+		heat := math.Log10(1 + float64(video.Clicks) + RECOMMEND_LIKE_WEIGHT*float64(likes[video.ID]) +
+			RECOMMEND_COLLECT_WEIGHT*float64(collects[video.ID]))
+		days := math.Max(time.Since(video.CreatedAt).Hours()/24, 0)
+		items[i] = recommend.Item{ID: video.ID, Score: heat * (1 + math.Pow(0.5, days/RECOMMEND_POPULAR_HALF_LIFE))}
+	}
+
+	members := make([]redis.Z, 0, RECOMMEND_POPULAR_COUNT)
+	for _, item := range recommend.Top(items, RECOMMEND_POPULAR_COUNT) {
+		members = append(members, redis.Z{Score: item.Score, Member: item.ID})
+	}
+	cache.SetPopularRecommend(members)
+}
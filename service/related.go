@@ -0,0 +1,154 @@
+package service
+
+import (
+	"clicli/cache"
+	"clicli/common"
+	"clicli/db/mysql"
+	"clicli/domain/model"
+	"clicli/recommend"
+	"clicli/search"
+	"github.com/go-redis/redis/v9"
+)
+
+// 缓存的相关视频数量
+const RELATED_VIDEO_COUNT = 30
+
+// 每种信号取出的候选数量
+const RELATED_CANDIDATE_COUNT = 50
+
+// 计算同时观看时使用的最近观看用户数量
+const RELATED_COWATCH_USERS = 500
+
+// 标题需要匹配的查询词比例
+const RELATED_TEXT_MINIMUM_MATCH = 0.3
+
+// 相关视频各项信号的权重
+var relatedWeights = recommend.RelatedWeights{
+	Partition:  1,
+	Author:     1,
+	Text:       2,
+	CoWatch:    2,
+	Popularity: 0.1,
+}
+
+// 获取相关视频，缓存不存在时重新计算
+func SelectRelatedVideo(videoId uint, count int) []model.Video {
+	ids, ok := cache.GetRelatedVideo(videoId, RELATED_VIDEO_COUNT)
+	if !ok {
+		ids = rebuildRelatedVideo(videoId)
+	}
+
+	// 缓存后可能被删除或重新审核
+	videoMap := selectVideoMap(ids)
+	videos := make([]model.Video, 0, count)
+	for _, id := range ids {
+		if len(videos) == count {
+			break
+		}
+		if video, ok := videoMap[id]; ok && video.Status == common.AUDIT_APPROVED {
+			videos = append(videos, video)
+		}
+	}
+	return videos
+}
+
+/**
+ * 计算相关视频并写入缓存
+ * 候选视频来自标题相似的视频、同分区和同作者的视频以及同时观看过的视频
+ */
+func rebuildRelatedVideo(videoId uint) []uint {
+	var video model.Video
+	mysqlClient.Where("id = ? and status = ?", videoId, common.AUDIT_APPROVED).First(&video)
+	if video.ID == 0 {
+		return nil
+	}
+
+	candidates := make(map[uint]*recommend.RelatedCandidate)
+	candidate := func(id uint) *recommend.RelatedCandidate {
+		if candidates[id] == nil {
+			candidates[id] = &recommend.RelatedCandidate{ID: id}
+		}
+		return candidates[id]
+	}
+
+	// 标题和简介的相关度，按最高分归一化，索引未建立时不缓存结果
+	var result search.Result
+	engine, err := getSearchEngine()
+	if err == nil {
+		result, err = engine.Search(search.Query{
+			Index:        search.INDEX_VIDEO,
+			Keywords:     video.Title,
+			Fields:       map[string]float64{"title": 2, "desc": 1},
+			MinimumMatch: RELATED_TEXT_MINIMUM_MATCH,
+			Limit:        RELATED_CANDIDATE_COUNT,
+		})
+		logSearchError(err)
+	}
+	if err == nil && len(result.Hits) > 0 {
+		max := result.Hits[0].Score
+		for _, hit := range result.Hits {
+			candidate(uint(hit.Attrs["id"])).Text = hit.Score / max
+		}
+	}
+
+	var ids []uint
+	mysqlClient.Scopes(mysql.ExcludeLegacyLive).Model(&model.Video{}).Where("partition_id = ? and status = ?", video.PartitionId, common.AUDIT_APPROVED).
+		Order("clicks desc").Limit(RELATED_CANDIDATE_COUNT).Pluck("id", &ids)
+	for _, id := range ids {
+		candidate(id)
+	}
+
+	ids = nil
+	mysqlClient.Scopes(mysql.ExcludeLegacyLive).Model(&model.Video{}).Where("uid = ? and status = ?", video.Uid, common.AUDIT_APPROVED).
+		Order("created_at desc").Limit(RELATED_CANDIDATE_COUNT).Pluck("id", &ids)
+	for _, id := range ids {
+		candidate(id)
+	}
+
+	// 同时观看的比例为观看过候选视频的用户占观看过该视频的用户的比例
+	var userIds []uint
+	mysqlClient.Model(&model.History{}).Where("vid = ?", videoId).Order("updated_at desc").
+		Limit(RELATED_COWATCH_USERS).Pluck("uid", &userIds)
+	if len(userIds) > 0 {
+		var cowatch []struct {
+			Vid   uint
+			Count int64
+		}
+		mysqlClient.Model(&model.History{}).Select("vid, count(*) as count").Where("uid in ? and vid <> ?", userIds, videoId).
+			Group("vid").Order("count desc").Limit(RELATED_CANDIDATE_COUNT).Scan(&cowatch)
+		for _, c := range cowatch {
+			candidate(c.Vid).CoWatch = float64(c.Count) / float64(len(userIds))
+		}
+	}
+
+	delete(candidates, videoId)
+	ids = make([]uint, 0, len(candidates))
+	for id := range candidates {
+		ids = append(ids, id)
+	}
+
+	list := make([]recommend.RelatedCandidate, 0, len(candidates))
+	for id, v := range selectVideoMap(ids) {
+		if v.Status != common.AUDIT_APPROVED {
+			continue
+		}
+		c := candidates[id]
+		c.SamePartition = v.PartitionId == video.PartitionId
+		c.SameAuthor = v.Uid == video.Uid
+		c.Clicks = v.Clicks
+		list = append(list, *c)
+	}
+
+	items := recommend.Related(list, relatedWeights, RELATED_VIDEO_COUNT)
+	members := make([]redis.Z, len(items))
+	related := make([]uint, len(items))
+	for i, item := range items {
+		members[i] = redis.Z{Score: item.Score, Member: item.ID}
+		related[i] = item.ID
+	}
+	if engine != nil {
+		cache.SetRelatedVideo(videoId, members)
+	}
+
+	return related
+}
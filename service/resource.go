@@ -1,6 +1,8 @@
 package service
 
 import (
+	"path"
+
 	"clicli/common"
 	"clicli/domain/dto"
 	"clicli/domain/model"
@@ -11,6 +13,35 @@ func InsertResource(resource model.Resource) uint {
 	return resource.ID
 }
 
+// 创建视频资源并添加转码任务，dirName为视频文件夹，原始文件为其中的upload.mp4
+func CreateVideoResource(vid, userId uint, dirName string, quality int, duration float64) (uint, error) {
+	// 生成url
+	url, err := GenerateFileUrl("video/" + dirName + "/index.mpd")
+	if err != nil {
+		return 0, err
+	}
+
+	hlsUrl, err := GenerateFileUrl("video/" + dirName + "/master.m3u8")
+	if err != nil {
+		return 0, err
+	}
+
+	// 原始文件只保存在本地
+	originalUrl := "video/" + dirName + "/upload.mp4"
+
+	// 存入数据库
+	resource := dto.ResourceDtoToResource(vid, userId, quality, duration, url, hlsUrl, originalUrl)
+	rid := InsertResource(resource)
+
+	// 添加转码任务
+	if err := EnqueueTransCoding(rid, quality, dirName); err != nil {
+		UpadteResourceStatus(rid, common.PROCESSING_FAIL)
+		return rid, err
+	}
+
+	return rid, nil
+}
+
 func SelectResourceByID(resourceId uint) (resource model.Resource) {
 	mysqlClient.First(&resource, resourceId)
 	return
@@ -36,9 +67,24 @@ func SelectResourceByVideo(videoId uint, pass bool) (resources []model.Resource)
 	}
 
 	db.Find(&resources)
+
+	// 附加字幕
+	resourceIds := make([]uint, len(resources))
+	for i := range resources {
+		resourceIds[i] = resources[i].ID
+	}
+	subtitles := SelectSubtitleByResource(resourceIds)
+	for i := range resources {
+		resources[i].Subtitles = subtitles[resources[i].ID]
+	}
 	return
 }
 
+// 分P是否存在(分P从1开始，对应视频的第几个资源)
+func IsVideoPartExist(videoId, part uint, pass bool) bool {
+	return part > 0 && int(part) <= len(SelectResourceByVideo(videoId, pass))
+}
+
 // 更新资源状态
 func UpadteResourceStatus(resourceId uint, status int) error {
 	err := mysqlClient.Model(&model.Resource{}).Where("id = ?", resourceId).Update("status", status).Error
@@ -60,4 +106,18 @@ func ModifyResourceTitleService(modifyDTO dto.ModifyResourceTitleDTO) error {
 // 删除资源
 func DeleteResource(id uint) {
 	mysqlClient.Where("id = ?", id).Delete(&model.Resource{})
+	DeleteSubtitleByResource(id)
+}
+
+// 更新资源预览图和候选封面
+func UpdateResourcePreview(resourceId uint, storyboard, covers string) error {
+	return mysqlClient.Model(&model.Resource{}).Where("id = ?", resourceId).Updates(map[string]interface{}{
+		"storyboard": storyboard,
+		"covers":     covers,
+	}).Error
+}
+
+// 获取资源对应的视频目录名
+func GetResourceDir(resource model.Resource) string {
+	return path.Base(path.Dir(resource.Url))
 }
@@ -0,0 +1,565 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"clicli/cache"
+	"clicli/common"
+	"clicli/db/mysql"
+	"clicli/domain/dto"
+	"clicli/domain/model"
+	"clicli/domain/vo"
+	"clicli/search"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// 检索字段和权重
+var (
+	videoSearchFields   = map[string]float64{"title": 3, "author": 2, "partition": 1.5, "desc": 1}
+	userSearchFields    = map[string]float64{"name": 3, "sign": 1}
+	commentSearchFields = map[string]float64{"content": 1}
+)
+
+// 排序时新发布和热度的加权
+const (
+	VIDEO_RECENCY_WEIGHT    = 0.5
+	VIDEO_POPULARITY_WEIGHT = 0.2
+	COMMENT_RECENCY_WEIGHT  = 0.3
+)
+
+// 默认索引的评论数量(包括回复)，只索引最近的评论，每个节点约占用200MB内存
+const DEFAULT_SEARCH_COMMENT_LIMIT = 100000
+
+/**
+ * 重建视频、用户和评论的搜索索引
+ * 读取数据失败时保留原来的索引，所有索引都重建成功时返回true
+ */
+func RebuildSearchIndex() bool {
+	if !beginSearchRebuild() {
+		zap.L().Info("搜索索引正在重建")
+		return false
+	}
+
+	start := time.Now()
+	zap.L().Info("开始重建搜索索引")
+
+	ok := true
+	videoIds, err := rebuildVideoIndex()
+	if err != nil {
+		ok = false
+		zap.L().Error("重建视频索引失败" + err.Error())
+	}
+	if err := rebuildUserIndex(); err != nil {
+		ok = false
+		zap.L().Error("重建用户索引失败" + err.Error())
+	}
+	// 评论只索引通过审核的视频，视频读取失败时不重建
+	if videoIds != nil {
+		if err := rebuildCommentIndex(videoIds); err != nil {
+			ok = false
+			zap.L().Error("重建评论索引失败" + err.Error())
+		}
+	}
+	endSearchRebuild(ok)
+	RebuildSearchSuggest()
+
+	zap.L().Info("搜索索引重建完成，耗时 " + time.Since(start).String())
+	return ok
+}
+
+// 每次更新的视频数量
+const SEARCH_STATS_BATCH = 500
+
+/**
+ * 更新点赞数或播放量有变化的视频在搜索索引中的属性
+ * 点赞和播放时只标记视频，由定时任务批量更新，只更新属性不重新读取视频
+ */
+func RefreshSearchStats() {
+	for {
+		ids := cache.PopSearchStats(SEARCH_STATS_BATCH)
+		if len(ids) == 0 {
+			return
+		}
+
+		likes, err := SelectLikeCounts(ids)
+		if err != nil {
+			// 放回集合，下次重试
+			for _, id := range ids {
+				cache.AddSearchStats(id)
+			}
+			zap.L().Error("获取点赞数失败" + err.Error())
+			return
+		}
+
+		for _, id := range ids {
+			clicks := float64(GetVideoClicks(id))
+			attrs := map[string]float64{"clicks": clicks, "likes": float64(likes[id])}
+			publishSearchAttrs(search.INDEX_VIDEO, strconv.Itoa(int(id)), attrs, clicks)
+		}
+	}
+}
+
+// 更新视频索引和标题的搜索建议，未通过审核或已删除的视频从索引中移除
+// 修改前的标题在下次重建搜索建议时移除
+func IndexVideo(videoId uint) {
+	var video model.Video
+	mysqlClient.Where("id = ? and status = ?", videoId, common.AUDIT_APPROVED).First(&video)
+	if video.ID == 0 {
+		publishSearchDelete(search.INDEX_VIDEO, strconv.Itoa(int(videoId)))
+		delVideoSuggest(videoId)
+		return
+	}
+	setVideoSuggest(video)
+
+	video.Author = GetUserInfo(video.Uid)
+	publishSearchIndex(search.INDEX_VIDEO, videoDocument(video, selectSearchPartitions(), selectVideoStats(video.ID)))
+}
+
+// 更新用户及其视频的索引(视频索引包含作者名)和用户名的搜索建议
+func IndexUser(userId uint) {
+	var user model.User
+	mysqlClient.Where("id = ? and status = ?", userId, "0").First(&user)
+	if user.ID == 0 {
+		publishSearchDelete(search.INDEX_USER, strconv.Itoa(int(userId)))
+		delUserSuggest(userId)
+		return
+	}
+	setUserSuggest(user)
+	publishSearchIndex(search.INDEX_USER, userDocument(user))
+
+	var videos []model.Video
+	mysqlClient.Scopes(mysql.ExcludeLegacyLive).Where("uid = ? and status = ?", userId, common.AUDIT_APPROVED).Find(&videos)
+	partitions := selectSearchPartitions()
+	for _, video := range videos {
+		video.Author = user
+		publishSearchIndex(search.INDEX_VIDEO, videoDocument(video, partitions, selectVideoStats(video.ID)))
+	}
+}
+
+// 添加评论索引
+func IndexComment(comment model.Comment) {
+	publishSearchIndex(search.INDEX_COMMENT, commentDocument(comment.ID.Hex(), comment.Vid, comment.Uid,
+		comment.Content, comment.CreatedAt))
+}
+
+// 添加回复索引
+func IndexReply(commentId primitive.ObjectID, reply model.Reply) {
+	var comment model.Comment
+	if err := mongoClient.Comment().FindOne(context.TODO(), bson.M{"_id": commentId}).Decode(&comment); err != nil {
+		zap.L().Error("获取评论失败" + err.Error())
+		return
+	}
+
+	publishSearchIndex(search.INDEX_COMMENT, commentDocument(replyDocumentId(commentId, reply.ID), comment.Vid,
+		reply.Uid, reply.Content, reply.CreatedAt))
+}
+
+// 删除评论及其回复的索引
+func DeleteCommentIndex(commentId primitive.ObjectID) {
+	var comment model.Comment
+	if err := mongoClient.Comment().FindOne(context.TODO(), bson.M{"_id": commentId}).Decode(&comment); err != nil {
+		zap.L().Error("获取评论失败" + err.Error())
+	}
+
+	publishSearchDelete(search.INDEX_COMMENT, commentId.Hex())
+	for _, reply := range comment.Reply {
+		publishSearchDelete(search.INDEX_COMMENT, replyDocumentId(commentId, reply.ID))
+	}
+}
+
+// 删除回复索引
+func DeleteReplyIndex(commentId, replyId primitive.ObjectID) {
+	publishSearchDelete(search.INDEX_COMMENT, replyDocumentId(commentId, replyId))
+}
+
+// 时长筛选区间(秒)，序号对应搜索参数中的时长区间减1
+var videoDurationRanges = []search.Range{
+	{Attr: "duration", Min: 0, Max: 600},
+	{Attr: "duration", Min: 600, Max: 1800},
+	{Attr: "duration", Min: 1800, Max: 3600},
+	{Attr: "duration", Min: 3600},
+}
+
+// 排序方式对应的属性
+var videoSortAttrs = map[string]string{
+	"clicks": "clicks",
+	"likes":  "likes",
+	"newest": "created_at",
+}
+
+// 搜索视频，返回排序后的视频、高亮字段和分面统计
+func SearchVideo(searchDTO dto.SearchVideoDTO) (int64, []model.Video, []map[string]string, vo.SearchFacetVO, error) {
+	query := search.Query{
+		Index:            search.INDEX_VIDEO,
+		Keywords:         searchDTO.Keywords,
+		Fields:           videoSearchFields,
+		Filters:          make(map[string]float64),
+		Sort:             videoSortAttrs[searchDTO.Sort],
+		Facets:           []search.Facet{{Attr: "partition"}, {Attr: "duration", Ranges: videoDurationRanges}},
+		Highlight:        []string{"title", "desc"},
+		RecencyWeight:    VIDEO_RECENCY_WEIGHT,
+		PopularityWeight: VIDEO_POPULARITY_WEIGHT,
+		Offset:           (searchDTO.Page - 1) * searchDTO.PageSize,
+		Limit:            searchDTO.PageSize,
+	}
+
+	if searchDTO.Partition != 0 {
+		if IsSubpartition(searchDTO.Partition) {
+			query.Filters["partition"] = float64(searchDTO.Partition)
+		} else {
+			query.Filters["parent_partition"] = float64(searchDTO.Partition)
+		}
+	}
+
+	if searchDTO.Duration > 0 {
+		query.Ranges = append(query.Ranges, videoDurationRanges[searchDTO.Duration-1])
+	}
+
+	// 1:原创 2:转载
+	if searchDTO.Copyright == 1 {
+		query.Filters["copyright"] = 1
+	} else if searchDTO.Copyright == 2 {
+		query.Filters["copyright"] = 0
+	}
+
+	// 结束日期包括当天
+	if begin, err := time.ParseInLocation("2006-01-02", searchDTO.BeginTime, time.Local); err == nil {
+		query.Ranges = append(query.Ranges, search.Range{Attr: "created_at", Min: float64(begin.Unix())})
+	}
+	if end, err := time.ParseInLocation("2006-01-02", searchDTO.EndTime, time.Local); err == nil {
+		query.Ranges = append(query.Ranges, search.Range{Attr: "created_at", Max: float64(end.AddDate(0, 0, 1).Unix())})
+	}
+
+	engine, err := getSearchEngine()
+	if err != nil {
+		return 0, nil, nil, vo.SearchFacetVO{}, err
+	}
+
+	result, err := engine.Search(query)
+	if err != nil {
+		return 0, nil, nil, vo.SearchFacetVO{}, err
+	}
+
+	ids := make([]uint, len(result.Hits))
+	for i, hit := range result.Hits {
+		ids[i] = uint(hit.Attrs["id"])
+	}
+
+	videos := make([]model.Video, 0, len(ids))
+	highlights := make([]map[string]string, 0, len(ids))
+	videoMap := selectVideoMap(ids)
+	for i, id := range ids {
+		if video, ok := videoMap[id]; ok {
+			videos = append(videos, video)
+			highlights = append(highlights, result.Hits[i].Highlights)
+		}
+	}
+
+	return int64(result.Total), videos, highlights, vo.ToSearchFacetVO(result.Facets), nil
+}
+
+// 搜索用户
+func SearchUser(keywords string, page, pageSize int) (int64, []model.User, []map[string]string, error) {
+	engine, err := getSearchEngine()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	result, err := engine.Search(search.Query{
+		Index:     search.INDEX_USER,
+		Keywords:  keywords,
+		Fields:    userSearchFields,
+		Highlight: []string{"name", "sign"},
+		Offset:    (page - 1) * pageSize,
+		Limit:     pageSize,
+	})
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	users := make([]model.User, 0, len(result.Hits))
+	highlights := make([]map[string]string, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		if user := GetUserInfo(uint(hit.Attrs["id"])); user.ID != 0 {
+			users = append(users, user)
+			highlights = append(highlights, hit.Highlights)
+		}
+	}
+
+	return int64(result.Total), users, highlights, nil
+}
+
+// 搜索评论和回复，videoId为0时搜索全部视频
+func SearchComment(keywords string, videoId uint, page, pageSize int) (int64, []search.Hit, error) {
+	query := search.Query{
+		Index:         search.INDEX_COMMENT,
+		Keywords:      keywords,
+		Fields:        commentSearchFields,
+		Highlight:     []string{"content"},
+		RecencyWeight: COMMENT_RECENCY_WEIGHT,
+		Offset:        (page - 1) * pageSize,
+		Limit:         pageSize,
+	}
+	if videoId != 0 {
+		query.Filters = map[string]float64{"vid": float64(videoId)}
+	}
+
+	engine, err := getSearchEngine()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	result, err := engine.Search(query)
+	if err != nil {
+		return 0, nil, err
+	}
+	return int64(result.Total), result.Hits, nil
+}
+
+// 回复的文档ID为 评论ID:回复ID
+func replyDocumentId(commentId, replyId primitive.ObjectID) string {
+	return commentId.Hex() + ":" + replyId.Hex()
+}
+
+func videoDocument(video model.Video, partitions map[uint]searchPartition, stats videoStats) search.Document {
+	partition := partitions[video.PartitionId]
+	var copyright float64
+	if video.Copyright {
+		copyright = 1
+	}
+
+	return search.Document{
+		ID: strconv.Itoa(int(video.ID)),
+		Fields: map[string]string{
+			"title":     video.Title,
+			"desc":      video.Desc,
+			"author":    video.Author.Username,
+			"partition": partition.name,
+		},
+		Attrs: map[string]float64{
+			"id":               float64(video.ID),
+			"uid":              float64(video.Uid),
+			"partition":        float64(video.PartitionId),
+			"parent_partition": float64(partition.parent),
+			"copyright":        copyright,
+			"duration":         stats.duration,
+			"clicks":           float64(video.Clicks),
+			"likes":            stats.likes,
+			"created_at":       float64(video.CreatedAt.Unix()),
+		},
+		Time:       video.CreatedAt,
+		Popularity: float64(video.Clicks),
+	}
+}
+
+func userDocument(user model.User) search.Document {
+	return search.Document{
+		ID: strconv.Itoa(int(user.ID)),
+		Fields: map[string]string{
+			"name": user.Username,
+			"sign": user.Sign,
+		},
+		Attrs: map[string]float64{
+			"id": float64(user.ID),
+		},
+		Time: user.CreatedAt,
+	}
+}
+
+func commentDocument(id string, videoId, userId uint, content string, createdAt int64) search.Document {
+	return search.Document{
+		ID: id,
+		Fields: map[string]string{
+			"content": content,
+		},
+		Attrs: map[string]float64{
+			"vid":        float64(videoId),
+			"uid":        float64(userId),
+			"created_at": float64(createdAt),
+		},
+		Time: time.UnixMilli(createdAt),
+	}
+}
+
+func rebuildVideoIndex() (map[uint]bool, error) {
+	var videos []model.Video
+	if err := mysqlClient.Scopes(mysql.ExcludeLegacyLive).Where("status = ?", common.AUDIT_APPROVED).Find(&videos).Error; err != nil {
+		return nil, err
+	}
+
+	var users []model.User
+	if err := mysqlClient.Select("id", "username").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uint]model.User, len(users))
+	for _, user := range users {
+		names[user.ID] = user
+	}
+
+	partitions := selectSearchPartitions()
+	stats := selectAllVideoStats()
+	videoIds := make(map[uint]bool, len(videos))
+	docs := make([]search.Document, len(videos))
+	for i, video := range videos {
+		video.Author = names[video.Uid]
+		docs[i] = videoDocument(video, partitions, stats[video.ID])
+		videoIds[video.ID] = true
+	}
+
+	return videoIds, search.GetEngine().Rebuild(search.INDEX_VIDEO, docs)
+}
+
+func rebuildUserIndex() error {
+	var users []model.User
+	if err := mysqlClient.Where("status = ?", "0").Find(&users).Error; err != nil {
+		return err
+	}
+
+	docs := make([]search.Document, len(users))
+	for i, user := range users {
+		docs[i] = userDocument(user)
+	}
+
+	return search.GetEngine().Rebuild(search.INDEX_USER, docs)
+}
+
+// 只索引通过审核的视频下最近的未删除评论和回复，数量由search.comment_limit配置
+func rebuildCommentIndex(videoIds map[uint]bool) error {
+	limit := viper.GetInt("search.comment_limit")
+	if limit <= 0 {
+		limit = DEFAULT_SEARCH_COMMENT_LIMIT
+	}
+
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cursor, err := mongoClient.Comment().Find(context.TODO(), bson.M{"is_delete": false}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	docs := make([]search.Document, 0)
+	for len(docs) < limit && cursor.Next(context.TODO()) {
+		var comment model.Comment
+		if err := cursor.Decode(&comment); err != nil {
+			return err
+		}
+		if !videoIds[comment.Vid] {
+			continue
+		}
+
+		docs = append(docs, commentDocument(comment.ID.Hex(), comment.Vid, comment.Uid, comment.Content, comment.CreatedAt))
+		for _, reply := range comment.Reply {
+			if !reply.IsDelete {
+				docs = append(docs, commentDocument(replyDocumentId(comment.ID, reply.ID), comment.Vid, reply.Uid,
+					reply.Content, reply.CreatedAt))
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	return search.GetEngine().Rebuild(search.INDEX_COMMENT, docs)
+}
+
+type searchPartition struct {
+	name   string // 分区名和上级分区名
+	parent uint   // 上级分区ID
+}
+
+// 视频的统计数据，用于筛选和排序
+type videoStats struct {
+	duration float64 // 所有分P的时长
+	likes    float64
+}
+
+func selectSearchPartitions() map[uint]searchPartition {
+	partitions := SelectPartition()
+	contents := make(map[uint]string, len(partitions))
+	for _, partition := range partitions {
+		contents[partition.ID] = partition.Content
+	}
+
+	result := make(map[uint]searchPartition, len(partitions))
+	for _, partition := range partitions {
+		name := partition.Content
+		if parent, ok := contents[partition.ParentId]; ok {
+			name += " " + parent
+		}
+		result[partition.ID] = searchPartition{name: name, parent: partition.ParentId}
+	}
+	return result
+}
+
+func selectVideoStats(videoId uint) (stats videoStats) {
+	mysqlClient.Model(&model.Resource{}).Select("coalesce(sum(duration), 0)").
+		Where("vid = ?", videoId).Scan(&stats.duration)
+
+	if likes, err := SelectLikeCount(videoId); err == nil {
+		stats.likes = float64(likes)
+	}
+	return
+}
+
+func selectAllVideoStats() map[uint]videoStats {
+	var durations []struct {
+		Vid      uint
+		Duration float64
+	}
+	mysqlClient.Model(&model.Resource{}).Select("vid, sum(duration) as duration").Group("vid").Scan(&durations)
+
+	stats := make(map[uint]videoStats, len(durations))
+	for _, d := range durations {
+		stats[d.Vid] = videoStats{duration: d.Duration}
+	}
+
+	cursor, err := mongoClient.Like().Aggregate(context.TODO(), bson.A{
+		bson.M{"$project": bson.M{"_id": 0, "vid": 1, "count": bson.M{"$size": "$user_ids"}}},
+	})
+	if err != nil {
+		zap.L().Error("获取点赞数失败" + err.Error())
+		return stats
+	}
+
+	var likes []struct {
+		Vid   uint  `bson:"vid"`
+		Count int32 `bson:"count"`
+	}
+	if err := cursor.All(context.TODO(), &likes); err != nil {
+		zap.L().Error("获取点赞数失败" + err.Error())
+		return stats
+	}
+	for _, like := range likes {
+		s := stats[like.Vid]
+		s.likes = float64(like.Count)
+		stats[like.Vid] = s
+	}
+	return stats
+}
+
+// 通过ID列表获取视频
+func selectVideoMap(ids []uint) map[uint]model.Video {
+	videoMap := make(map[uint]model.Video, len(ids))
+	if len(ids) == 0 {
+		return videoMap
+	}
+
+	var videos []model.Video
+	mysqlClient.Where("id in ?", ids).Find(&videos)
+	for _, video := range videos {
+		videoMap[video.ID] = video
+	}
+	return videoMap
+}
+
+func logSearchError(err error) {
+	if err != nil {
+		zap.L().Error("更新搜索索引失败" + err.Error())
+	}
+}
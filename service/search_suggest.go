@@ -0,0 +1,267 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"clicli/cache"
+	"clicli/common"
+	"clicli/db/mysql"
+	"clicli/domain/model"
+	"clicli/domain/vo"
+	"clicli/search"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 记录和建议的搜索词最大长度
+const MAX_SEARCH_KEYWORD_LENGTH = 30
+
+// 返回的搜索建议数量
+const SEARCH_SUGGEST_COUNT = 10
+
+// 前缀匹配的候选数量，按分数排序后截取
+const SEARCH_SUGGEST_CANDIDATES = 50
+
+// 搜索词达到该次数后才会在重建时加入搜索建议
+const SEARCH_SUGGEST_MIN_COUNT = 10
+
+// 默认每个统计窗口内记录的搜索次数
+const DEFAULT_SEARCH_RATE_LIMIT = 10
+
+// 热门搜索统计最近24小时，每6小时权重减半
+const (
+	SEARCH_TRENDING_HOURS     = 24
+	SEARCH_TRENDING_HALF_LIFE = 6
+	SEARCH_TRENDING_COUNT     = 10
+)
+
+// 规范化搜索词，超出长度时截断
+func NormalizeSearchKeyword(keywords string) string {
+	keyword := search.Normalize(keywords)
+	if utf8.RuneCountInString(keyword) > MAX_SEARCH_KEYWORD_LENGTH {
+		keyword = strings.TrimSpace(string([]rune(keyword)[:MAX_SEARCH_KEYWORD_LENGTH]))
+	}
+	return keyword
+}
+
+/**
+ * 记录搜索词，过长或被屏蔽的词不记录
+ * 同一客户端超过搜索频率或重复搜索同一个词时不计数，避免刷热门搜索
+ * 搜索词不会直接加入搜索建议，重建时只加入搜索次数足够多的词
+ */
+func RecordSearch(keywords, client string) {
+	keyword := search.Normalize(keywords)
+	if keyword == "" || utf8.RuneCountInString(keyword) > MAX_SEARCH_KEYWORD_LENGTH {
+		return
+	}
+	if searchBlocked(keyword, cache.GetSearchBlock()) {
+		return
+	}
+	if !allowRecordSearch(client) || !cache.SetSearchRecord(client, keyword) {
+		return
+	}
+
+	cache.IncrSearchCount(keyword)
+}
+
+// 检查客户端搜索的频率
+func allowRecordSearch(client string) bool {
+	limit := viper.GetInt64("search.rate_limit")
+	if limit <= 0 {
+		limit = DEFAULT_SEARCH_RATE_LIMIT
+	}
+	return cache.IncrSearchRate(client) <= limit
+}
+
+// 获取前缀匹配的搜索建议，包括视频标题、用户名和搜索过的词
+func SelectSearchSuggest(keywords string) []string {
+	prefix := NormalizeSearchKeyword(keywords)
+	if prefix == "" {
+		return make([]string, 0)
+	}
+
+	candidates := cache.GetSearchSuggest(prefix, SEARCH_SUGGEST_CANDIDATES)
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+
+	blocks := cache.GetSearchBlock()
+	seen := make(map[string]bool)
+	suggestions := make([]string, 0, SEARCH_SUGGEST_COUNT)
+	for _, candidate := range candidates {
+		display, _ := candidate.Member.(string)
+		if seen[display] || searchBlocked(display, blocks) {
+			continue
+		}
+
+		seen[display] = true
+		suggestions = append(suggestions, display)
+		if len(suggestions) == SEARCH_SUGGEST_COUNT {
+			break
+		}
+	}
+	return suggestions
+}
+
+// 获取热门搜索，越近的搜索权重越高
+func SelectTrendingSearch() []vo.TrendingSearchVO {
+	// 多取一些，过滤屏蔽词后仍有足够数量
+	trending := cache.GetTrendingSearch(SEARCH_TRENDING_HOURS, SEARCH_TRENDING_HALF_LIFE, SEARCH_TRENDING_COUNT*2)
+
+	blocks := cache.GetSearchBlock()
+	list := make([]vo.TrendingSearchVO, 0, SEARCH_TRENDING_COUNT)
+	for _, item := range trending {
+		keyword, _ := item.Member.(string)
+		if searchBlocked(keyword, blocks) {
+			continue
+		}
+
+		list = append(list, vo.TrendingSearchVO{Keyword: keyword, Score: math.Round(item.Score*100) / 100})
+		if len(list) == SEARCH_TRENDING_COUNT {
+			break
+		}
+	}
+	return list
+}
+
+// 按搜索次数获取搜索词，标记是否被屏蔽
+func SelectHotSearchList(page, pageSize int) (int64, []vo.HotSearchVO) {
+	start := int64((page - 1) * pageSize)
+	hot := cache.GetSearchCount(start, start+int64(pageSize)-1)
+
+	blocks := cache.GetSearchBlock()
+	list := make([]vo.HotSearchVO, len(hot))
+	for i, item := range hot {
+		keyword, _ := item.Member.(string)
+		list[i] = vo.HotSearchVO{Keyword: keyword, Count: int64(item.Score), Blocked: searchBlocked(keyword, blocks)}
+	}
+	return cache.GetSearchCountTotal(), list
+}
+
+func SelectSearchBlockList() []string {
+	blocks := cache.GetSearchBlock()
+	sort.Strings(blocks)
+	return blocks
+}
+
+// 添加屏蔽词，包含屏蔽词的搜索建议和热门搜索不再展示
+func InsertSearchBlock(word string) {
+	cache.AddSearchBlock(search.Normalize(word))
+}
+
+func DeleteSearchBlock(word string) {
+	cache.DelSearchBlock(search.Normalize(word))
+}
+
+// 重建搜索建议，包括通过审核的视频标题、正常状态的用户名和搜索次数达到要求的词
+func RebuildSearchSuggest() {
+	// 所有节点启动和定时任务都会重建，同一时间只由一个节点重建
+	token := cache.LockSearchSuggest()
+	if token == "" {
+		zap.L().Info("其他节点正在重建搜索建议")
+		return
+	}
+	defer cache.UnlockSearchSuggest(token)
+
+	start := time.Now()
+	cache.TrimSearchCount()
+
+	scores := make(map[string]float64)
+	add := func(display string, score float64) {
+		if display = strings.TrimSpace(display); display == "" {
+			return
+		}
+		if s, ok := scores[display]; !ok || score > s {
+			scores[display] = score
+		}
+	}
+
+	// 读取失败时保留原来的搜索建议
+	var videos []model.Video
+	if err := mysqlClient.Scopes(mysql.ExcludeLegacyLive).Select("title", "clicks").
+		Where("status = ?", common.AUDIT_APPROVED).Find(&videos).Error; err != nil {
+		zap.L().Error("重建搜索建议失败" + err.Error())
+		return
+	}
+	for _, video := range videos {
+		add(video.Title, videoSuggestScore(video.Clicks))
+	}
+
+	var users []model.User
+	if err := mysqlClient.Select("username").Where("status = ?", "0").Find(&users).Error; err != nil {
+		zap.L().Error("重建搜索建议失败" + err.Error())
+		return
+	}
+	for _, user := range users {
+		add(user.Username, 0)
+	}
+
+	// 按搜索次数从高到低排列，次数不足时结束
+	for _, item := range cache.GetSearchCount(0, -1) {
+		if item.Score < SEARCH_SUGGEST_MIN_COUNT {
+			break
+		}
+		keyword, _ := item.Member.(string)
+		add(keyword, item.Score)
+	}
+
+	normalized := make([]string, 0, len(scores))
+	display := make([]string, 0, len(scores))
+	values := make([]float64, 0, len(scores))
+	for d, score := range scores {
+		normalized = append(normalized, NormalizeSearchKeyword(d))
+		display = append(display, d)
+		values = append(values, score)
+	}
+
+	if err := cache.ResetSearchSuggest(token, normalized, display, values); err != nil {
+		zap.L().Error("重建搜索建议失败" + err.Error())
+		return
+	}
+	zap.L().Info("搜索建议重建完成，共" + strconv.Itoa(len(display)) + "条，耗时 " + time.Since(start).String())
+}
+
+// 更新视频标题的搜索建议
+func setVideoSuggest(video model.Video) {
+	cache.SetSearchSuggest(NormalizeSearchKeyword(video.Title), video.Title, videoSuggestScore(video.Clicks))
+}
+
+func delVideoSuggest(videoId uint) {
+	var video model.Video
+	mysqlClient.Unscoped().Select("title").Where("id = ?", videoId).First(&video)
+	if video.Title != "" {
+		cache.DelSearchSuggest(NormalizeSearchKeyword(video.Title), video.Title)
+	}
+}
+
+// 更新用户名的搜索建议
+func setUserSuggest(user model.User) {
+	cache.SetSearchSuggest(NormalizeSearchKeyword(user.Username), user.Username, 0)
+}
+
+func delUserSuggest(userId uint) {
+	var user model.User
+	mysqlClient.Unscoped().Select("username").Where("id = ?", userId).First(&user)
+	if user.Username != "" {
+		cache.DelSearchSuggest(NormalizeSearchKeyword(user.Username), user.Username)
+	}
+}
+
+// 视频标题的建议分数，按播放量的对数计算
+func videoSuggestScore(clicks int64) float64 {
+	return math.Log10(1 + float64(clicks))
+}
+
+// 内容是否包含屏蔽词或违禁词
+func searchBlocked(text string, blocks []string) bool {
+	normalized := search.Normalize(text)
+	for _, block := range blocks {
+		if block != "" && strings.Contains(normalized, block) {
+			return true
+		}
+	}
+	return ContainsFilterWord(text)
+}
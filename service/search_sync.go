@@ -0,0 +1,158 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"clicli/cache"
+	"clicli/search"
+	"go.uber.org/zap"
+)
+
+// 索引更新操作
+const (
+	SEARCH_OP_INDEX  = "index"
+	SEARCH_OP_DELETE = "delete"
+	SEARCH_OP_ATTRS  = "attrs"
+)
+
+// 第一次建立索引失败后的重试间隔(分钟)
+const SEARCH_REBUILD_RETRY = 1
+
+var ErrSearchNotReady = errors.New("search index not ready")
+
+// 节点间传递的索引更新
+type searchIndexMessage struct {
+	Op    string          `json:"op"`
+	Index string          `json:"index"`
+	ID    string          `json:"id,omitempty"`
+	Doc   search.Document `json:"doc,omitempty"`
+}
+
+/**
+ * 每个节点都有自己的内存索引，索引更新通过Redis发布到所有节点(包括当前节点)后再写入
+ * 重建期间收到的更新会在重建完成后重新写入，避免被重建前读取的数据覆盖
+ */
+var searchSync struct {
+	mux        sync.Mutex
+	ready      bool // 第一次重建是否完成
+	rebuilding bool
+	pending    []searchIndexMessage
+}
+
+// 订阅其他节点的索引更新，并在后台建立索引
+func InitSearch() {
+	cache.Subscribe(cache.SEARCH_INDEX_CHANNEL, func(payload string) {
+		var msg searchIndexMessage
+		if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+			zap.L().Error("解析索引更新失败" + err.Error())
+			return
+		}
+		applySearchMessage(msg)
+	})
+
+	// 第一次建立索引失败时重试，成功前不提供搜索
+	go func() {
+		for !RebuildSearchIndex() {
+			if searchReady() {
+				return
+			}
+			time.Sleep(SEARCH_REBUILD_RETRY * time.Minute)
+		}
+	}()
+}
+
+func searchReady() bool {
+	searchSync.mux.Lock()
+	defer searchSync.mux.Unlock()
+
+	return searchSync.ready
+}
+
+// 第一次建立索引完成前搜索结果不完整，返回ErrSearchNotReady
+func getSearchEngine() (search.Engine, error) {
+	searchSync.mux.Lock()
+	defer searchSync.mux.Unlock()
+
+	if !searchSync.ready {
+		return nil, ErrSearchNotReady
+	}
+	return search.GetEngine(), nil
+}
+
+// 开始重建，已经在重建时返回false
+func beginSearchRebuild() bool {
+	searchSync.mux.Lock()
+	defer searchSync.mux.Unlock()
+
+	if searchSync.rebuilding {
+		return false
+	}
+	searchSync.rebuilding = true
+	searchSync.pending = nil
+	return true
+}
+
+// 重建完成，按顺序重新写入重建期间的更新，重建成功后开始提供搜索
+func endSearchRebuild(ok bool) {
+	searchSync.mux.Lock()
+	defer searchSync.mux.Unlock()
+
+	for _, msg := range searchSync.pending {
+		writeSearchMessage(msg)
+	}
+	searchSync.pending = nil
+	searchSync.rebuilding = false
+	if ok {
+		searchSync.ready = true
+	}
+}
+
+func publishSearchIndex(index string, doc search.Document) {
+	publishSearchMessage(searchIndexMessage{Op: SEARCH_OP_INDEX, Index: index, ID: doc.ID, Doc: doc})
+}
+
+// 只更新属性和热度，Doc中只有Attrs和Popularity
+func publishSearchAttrs(index, id string, attrs map[string]float64, popularity float64) {
+	publishSearchMessage(searchIndexMessage{Op: SEARCH_OP_ATTRS, Index: index, ID: id,
+		Doc: search.Document{Attrs: attrs, Popularity: popularity}})
+}
+
+func publishSearchDelete(index, id string) {
+	publishSearchMessage(searchIndexMessage{Op: SEARCH_OP_DELETE, Index: index, ID: id})
+}
+
+// 发布失败时只更新当前节点
+func publishSearchMessage(msg searchIndexMessage) {
+	data, err := json.Marshal(msg)
+	if err == nil {
+		err = cache.Publish(cache.SEARCH_INDEX_CHANNEL, data)
+	}
+	if err != nil {
+		zap.L().Error("发布索引更新失败" + err.Error())
+		applySearchMessage(msg)
+	}
+}
+
+func applySearchMessage(msg searchIndexMessage) {
+	searchSync.mux.Lock()
+	defer searchSync.mux.Unlock()
+
+	if searchSync.rebuilding {
+		searchSync.pending = append(searchSync.pending, msg)
+	}
+	writeSearchMessage(msg)
+}
+
+func writeSearchMessage(msg searchIndexMessage) {
+	switch msg.Op {
+	case SEARCH_OP_INDEX:
+		logSearchError(search.GetEngine().Index(msg.Index, msg.Doc))
+	case SEARCH_OP_DELETE:
+		logSearchError(search.GetEngine().Delete(msg.Index, msg.ID))
+	case SEARCH_OP_ATTRS:
+		logSearchError(search.GetEngine().UpdateAttrs(msg.Index, msg.ID, msg.Doc.Attrs, msg.Doc.Popularity))
+	}
+}
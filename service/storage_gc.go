@@ -0,0 +1,278 @@
+package service
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"clicli/common"
+	"clicli/domain/model"
+	"clicli/domain/vo"
+	"clicli/storage"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 默认宽限期 n 小时，删除或失败超过宽限期的文件才会被清理
+const DEFAULT_GC_GRACE_PERIOD = 72
+
+// 获取存储清理配置，未配置时只生成报告不删除
+func GetStorageGcConfig() (bool, int) {
+	dryRun := !viper.IsSet("gc.dry_run") || viper.GetBool("gc.dry_run")
+	gracePeriod := viper.GetInt("gc.grace_period")
+	if gracePeriod <= 0 {
+		gracePeriod = DEFAULT_GC_GRACE_PERIOD
+	}
+	return dryRun, gracePeriod
+}
+
+// 对比数据库和存储，清理已删除视频、失败转码、未使用的图片和字幕
+func RunStorageGC(dryRun bool) vo.StorageGcReportVO {
+	_, gracePeriod := GetStorageGcConfig()
+	cutoff := time.Now().Add(-time.Hour * time.Duration(gracePeriod))
+	report := vo.StorageGcReportVO{
+		DryRun:      dryRun,
+		GracePeriod: gracePeriod,
+		Videos:      make([]string, 0),
+		Images:      make([]string, 0),
+		Subtitles:   make([]string, 0),
+		Errors:      make([]string, 0),
+	}
+
+	// 查询失败时使用中的文件不完整，不能清理
+	videoDirs, err := selectReferencedVideoDirs(cutoff)
+	if err != nil {
+		return abortStorageGC(report, "查询使用中的视频文件失败:"+err.Error())
+	}
+	images, err := selectReferencedImages(cutoff)
+	if err != nil {
+		return abortStorageGC(report, "查询使用中的图片失败:"+err.Error())
+	}
+	subtitles, err := selectReferencedSubtitles(cutoff)
+	if err != nil {
+		return abortStorageGC(report, "查询使用中的字幕失败:"+err.Error())
+	}
+	videos := make(map[string]bool)
+	unusedImages := make(map[string]bool)
+	unusedSubtitles := make(map[string]bool)
+
+	// 本地文件和远程存储都需要清理
+	stores := []storage.Storage{storage.NewLocal(storage.LOCAL_ROOT, storage.LOCAL_URL_PREFIX)}
+	if current := storage.GetStorage(); !storage.IsLocal(current) {
+		stores = append(stores, current)
+	}
+
+	for _, s := range stores {
+		// 视频文件夹，整个文件夹的文件都超过宽限期才清理
+		objects, err := s.ListObjects("video/")
+		if err != nil {
+			report.Errors = append(report.Errors, "列举视频文件失败:"+err.Error())
+		}
+		if !hasReferenced("视频文件", videoDirs, objects, &report) {
+			objects = nil
+		}
+		for dir, dirObjects := range groupVideoObjects(objects) {
+			if videoDirs[dir] || !expired(dirObjects, cutoff) {
+				continue
+			}
+			videos[dir] = true
+			deleteObjects(s, dirObjects, dryRun, &report)
+		}
+
+		// 图片
+		objects, err = s.ListObjects("image/")
+		if err != nil {
+			report.Errors = append(report.Errors, "列举图片失败:"+err.Error())
+		}
+		if !hasReferenced("图片", images, objects, &report) {
+			objects = nil
+		}
+		for _, object := range objects {
+			name := path.Base(object.Key)
+			if images[name] || object.LastModified.After(cutoff) {
+				continue
+			}
+			unusedImages[name] = true
+			deleteObjects(s, []storage.ObjectInfo{object}, dryRun, &report)
+		}
+
+		// 字幕
+		objects, err = s.ListObjects("subtitle/")
+		if err != nil {
+			report.Errors = append(report.Errors, "列举字幕失败:"+err.Error())
+		}
+		if !hasReferenced("字幕", subtitles, objects, &report) {
+			objects = nil
+		}
+		for _, object := range objects {
+			name := path.Base(object.Key)
+			if subtitles[name] || object.LastModified.After(cutoff) {
+				continue
+			}
+			unusedSubtitles[name] = true
+			deleteObjects(s, []storage.ObjectInfo{object}, dryRun, &report)
+		}
+	}
+
+	for dir := range videos {
+		report.Videos = append(report.Videos, dir)
+	}
+	for name := range unusedImages {
+		report.Images = append(report.Images, name)
+	}
+	for name := range unusedSubtitles {
+		report.Subtitles = append(report.Subtitles, name)
+	}
+	sort.Strings(report.Videos)
+	sort.Strings(report.Images)
+	sort.Strings(report.Subtitles)
+
+	return report
+}
+
+// 停止清理，不删除任何文件
+func abortStorageGC(report vo.StorageGcReportVO, msg string) vo.StorageGcReportVO {
+	report.Errors = append(report.Errors, msg)
+	zap.L().Error("存储清理停止," + msg)
+	return report
+}
+
+// 数据库中没有使用中的文件但存储中有文件，可能是数据异常，跳过这类文件
+func hasReferenced(name string, referenced map[string]bool, objects []storage.ObjectInfo, report *vo.StorageGcReportVO) bool {
+	if len(referenced) == 0 && len(objects) > 0 {
+		report.Errors = append(report.Errors, "数据库中没有使用中的"+name+"，跳过清理")
+		zap.L().Error("数据库中没有使用中的" + name + "，跳过清理")
+		return false
+	}
+	return true
+}
+
+// 获取仍在使用的视频文件夹(包括宽限期内删除的资源)
+func selectReferencedVideoDirs(cutoff time.Time) (map[string]bool, error) {
+	var urls []string
+	if err := mysqlClient.Unscoped().Model(&model.Resource{}).
+		Joins("left join video on video.id = resource.vid").
+		Where("(resource.deleted_at is null or resource.deleted_at > ?)", cutoff).
+		Where("(video.deleted_at is null or video.deleted_at > ?)", cutoff).
+		Where("(resource.status <> ? or resource.updated_at > ?)", common.PROCESSING_FAIL, cutoff).
+		Pluck("resource.url", &urls).Error; err != nil {
+		return nil, err
+	}
+
+	dirs := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		dirs[GetResourceDir(model.Resource{Url: url})] = true
+	}
+
+	// 转码中的任务
+	var taskDirs []string
+	if err := mysqlClient.Model(&model.TranscodingTask{}).
+		Where("status in ?", []int{common.TASK_PENDING, common.TASK_RUNNING}).Pluck("dir", &taskDirs).Error; err != nil {
+		return nil, err
+	}
+	for _, dir := range taskDirs {
+		dirs[dir] = true
+	}
+
+	// 录制中的直播录像
+	var recordDirs []string
+	if err := mysqlClient.Model(&model.LiveRecord{}).
+		Where("status = ? and created_at > ?", common.LIVE_RECORDING, cutoff).Pluck("dir", &recordDirs).Error; err != nil {
+		return nil, err
+	}
+	for _, dir := range recordDirs {
+		dirs[dir] = true
+	}
+
+	return dirs, nil
+}
+
+// 获取仍在使用的图片文件名
+func selectReferencedImages(cutoff time.Time) (map[string]bool, error) {
+	queries := []struct {
+		db     *gorm.DB
+		column string
+	}{
+		{mysqlClient.Unscoped().Model(&model.Video{}).Where("deleted_at is null or deleted_at > ?", cutoff), "cover"},
+		{mysqlClient.Unscoped().Model(&model.LiveRoom{}).Where("deleted_at is null or deleted_at > ?", cutoff), "cover"},
+		{mysqlClient.Model(&model.User{}), "avatar"},
+		{mysqlClient.Model(&model.User{}), "space_cover"},
+		{mysqlClient.Model(&model.Carousel{}), "img"},
+		{mysqlClient.Model(&model.Collection{}), "cover"},
+	}
+
+	images := make(map[string]bool)
+	for _, query := range queries {
+		var urls []string
+		if err := query.db.Pluck(query.column, &urls).Error; err != nil {
+			return nil, err
+		}
+		for _, url := range urls {
+			if url != "" {
+				images[path.Base(url)] = true
+			}
+		}
+	}
+
+	return images, nil
+}
+
+// 获取仍在使用的字幕文件名(包括宽限期内删除的字幕、资源和视频)
+func selectReferencedSubtitles(cutoff time.Time) (map[string]bool, error) {
+	var urls []string
+	if err := mysqlClient.Unscoped().Model(&model.Subtitle{}).
+		Joins("left join resource on resource.id = subtitle.rid").
+		Joins("left join video on video.id = resource.vid").
+		Where("(subtitle.deleted_at is null or subtitle.deleted_at > ?)", cutoff).
+		Where("(resource.deleted_at is null or resource.deleted_at > ?)", cutoff).
+		Where("(video.deleted_at is null or video.deleted_at > ?)", cutoff).
+		Pluck("subtitle.url", &urls).Error; err != nil {
+		return nil, err
+	}
+
+	subtitles := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		subtitles[path.Base(url)] = true
+	}
+	return subtitles, nil
+}
+
+// 按视频文件夹分组
+func groupVideoObjects(objects []storage.ObjectInfo) map[string][]storage.ObjectInfo {
+	groups := make(map[string][]storage.ObjectInfo)
+	for _, object := range objects {
+		parts := strings.SplitN(object.Key, "/", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		groups[parts[1]] = append(groups[parts[1]], object)
+	}
+	return groups
+}
+
+// 所有文件都超过宽限期
+func expired(objects []storage.ObjectInfo, cutoff time.Time) bool {
+	for _, object := range objects {
+		if object.LastModified.After(cutoff) {
+			return false
+		}
+	}
+	return true
+}
+
+func deleteObjects(s storage.Storage, objects []storage.ObjectInfo, dryRun bool, report *vo.StorageGcReportVO) {
+	for _, object := range objects {
+		report.Objects++
+		report.Size += object.Size
+		if dryRun {
+			continue
+		}
+
+		if err := s.DeleteObject(object.Key); err != nil {
+			report.Errors = append(report.Errors, "删除"+object.Key+"失败:"+err.Error())
+			zap.L().Error("删除" + object.Key + "失败:" + err.Error())
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"mime"
+
+	"clicli/domain/dto"
+	"clicli/domain/model"
+	"clicli/storage"
+	"clicli/util/subtitle"
+)
+
+// 字幕文件最大大小(MB)
+const MAX_SUBTITLE_FILE_SIZE = 2
+
+// 每个资源的最大字幕数量
+const MAX_SUBTITLE_COUNT = 20
+
+var (
+	ErrSubtitleCount = errors.New("subtitle count exceeds limit")
+	ErrSubtitleExist = errors.New("subtitle language already exists")
+)
+
+func init() {
+	// 部分系统的mime表中没有vtt，浏览器需要正确的类型才能加载字幕
+	mime.AddExtensionType(".vtt", "text/vtt")
+}
+
+// 保存字幕，SRT或WebVTT统一转为WebVTT后上传到存储
+func SaveSubtitle(data []byte) (string, error) {
+	vtt, err := subtitle.ToVTT(data)
+	if err != nil {
+		return "", err
+	}
+
+	objectKey := "subtitle/" + GenerateSubtitleFilename()
+	if err := storage.GetStorage().PutObject(objectKey, bytes.NewReader(vtt)); err != nil {
+		return "", err
+	}
+
+	return GenerateFileUrl(objectKey)
+}
+
+// 添加字幕，同一语言的字幕已存在时替换字幕文件
+func InsertSubtitle(subtitle model.Subtitle) (model.Subtitle, error) {
+	var existing model.Subtitle
+	mysqlClient.Where("rid = ? and lang = ?", subtitle.Rid, subtitle.Lang).First(&existing)
+	if existing.ID != 0 {
+		existing.Label = subtitle.Label
+		existing.Url = subtitle.Url
+		err := mysqlClient.Model(&existing).Updates(map[string]interface{}{
+			"label": existing.Label,
+			"url":   existing.Url,
+		}).Error
+		return existing, err
+	}
+
+	var count int64
+	mysqlClient.Model(&model.Subtitle{}).Where("rid = ?", subtitle.Rid).Count(&count)
+	if count >= MAX_SUBTITLE_COUNT {
+		return subtitle, ErrSubtitleCount
+	}
+
+	err := mysqlClient.Create(&subtitle).Error
+	return subtitle, err
+}
+
+func SelectSubtitleByID(id uint) (subtitle model.Subtitle) {
+	mysqlClient.First(&subtitle, id)
+	return
+}
+
+// 获取资源的字幕
+func SelectSubtitleByResource(resourceIds []uint) map[uint][]model.Subtitle {
+	subtitles := make(map[uint][]model.Subtitle)
+	if len(resourceIds) == 0 {
+		return subtitles
+	}
+
+	var list []model.Subtitle
+	mysqlClient.Where("rid in ?", resourceIds).Order("id").Find(&list)
+	for _, s := range list {
+		subtitles[s.Rid] = append(subtitles[s.Rid], s)
+	}
+	return subtitles
+}
+
+// 修改字幕语言和名称
+func ModifySubtitle(modifyDTO dto.ModifySubtitleDTO) error {
+	subtitle := SelectSubtitleByID(modifyDTO.ID)
+
+	var count int64
+	mysqlClient.Model(&model.Subtitle{}).
+		Where("rid = ? and lang = ? and id <> ?", subtitle.Rid, modifyDTO.Lang, modifyDTO.ID).Count(&count)
+	if count > 0 {
+		return ErrSubtitleExist
+	}
+
+	return mysqlClient.Model(&model.Subtitle{}).Where("id = ?", modifyDTO.ID).Updates(map[string]interface{}{
+		"lang":  modifyDTO.Lang,
+		"label": modifyDTO.Label,
+	}).Error
+}
+
+// 删除字幕，文件由存储清理任务在宽限期后删除
+func DeleteSubtitle(id uint) {
+	mysqlClient.Where("id = ?", id).Delete(&model.Subtitle{})
+}
+
+// 删除资源的所有字幕
+func DeleteSubtitleByResource(resourceId uint) {
+	mysqlClient.Where("rid = ?", resourceId).Delete(&model.Subtitle{})
+}
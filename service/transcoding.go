@@ -1,118 +1,402 @@
 package service
 
 import (
+	"encoding/json"
 	"errors"
 	"os"
-
 	"strconv"
+	"strings"
+	"time"
 
 	"clicli/common"
-	"clicli/util/number"
+	"clicli/domain/model"
+	"clicli/domain/valid"
+	"clicli/util/convert"
 	"clicli/util/transcoding"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
+// 视频默认最大边长(像素)
+const DEFAULT_MAX_VIDEO_DIMENSION = 4096
+
+var (
+	ErrVideoDecode    = errors.New("video decode failed")
+	ErrVideoDimension = errors.New("video dimension exceeds limit")
+)
+
 // 预处理视频
 func PreTreatmentVideo(input string) (int, float64, error) {
 	videoData, err := transcoding.GetVideoInfo(input)
 	if err != nil {
-		return 0, 0, err
+		zap.L().Error("获取视频信息失败" + err.Error())
+		return 0, 0, ErrVideoDecode
+	}
+
+	// 不同编码的视频在转码时统一处理，这里只要求存在视频流
+	video, ok := videoData.VideoStream()
+	if !ok {
+		return 0, 0, ErrVideoDecode
 	}
 
-	if videoData.Stream[0].CodecName != "h264" {
-		return 0, 0, errors.New("not h264")
+	//校验分辨率
+	width, height := video.DisplaySize()
+	if !valid.MediaDimension(width, height, GetMaxVideoDimension()) {
+		return 0, 0, ErrVideoDimension
 	}
 
 	//计算最大分辨率
-	width := videoData.Stream[0].Width
-	height := videoData.Stream[0].Height
-	quality := number.Min(getWidthRes(width), getHeigthRes(height))
+	quality := GetEncodingConfig().MaxQuality(width, height)
 
 	//获取视频时长
-	duration, _ := strconv.ParseFloat(videoData.Stream[0].Duration, 64)
+	duration := videoData.GetDuration()
+	if duration <= 0 {
+		return 0, 0, ErrVideoDecode
+	}
 
-	return quality, duration, err
+	return quality, duration, nil
 }
 
-// 转码
-func VideoTransCoding(resourceId uint, quality int, dirName string) {
-	localDir := "./upload/video/" + dirName + "/"
-	inputFile := localDir + "upload.mp4"
+// 获取视频最大边长
+func GetMaxVideoDimension() int {
+	if dimension := viper.GetInt("file.max_video_dimension"); dimension > 0 {
+		return dimension
+	}
+	return DEFAULT_MAX_VIDEO_DIMENSION
+}
+
+// 转码worker默认数量
+const DEFAULT_TRANSCODING_WORKERS = 2
+
+// 转码任务默认最大重试次数
+const DEFAULT_TRANSCODING_MAX_RETRY = 3
 
-	// 提取音频
-	audioFile, err := transcoding.ExtractingAudio(inputFile, localDir)
+// 转码任务重试间隔基数，第n次重试等待 n 的指数倍
+const TRANSCODING_RETRY_BACKOFF = 30 * time.Second
+
+// 没有新任务通知时，worker轮询数据库的间隔
+const TRANSCODING_POLL_INTERVAL = 10 * time.Second
+
+// 自动生成的候选封面数量
+const VIDEO_COVER_CANDIDATES = 3
+
+// 通知worker有新任务
+var transcodingSignal chan struct{}
+
+// 初始化转码队列
+func InitTransCodingQueue() {
+	workers := viper.GetInt("transcoding.workers")
+	if workers <= 0 {
+		workers = DEFAULT_TRANSCODING_WORKERS
+	}
+	transcodingSignal = make(chan struct{}, workers)
+
+	// 恢复服务重启前未完成的任务
+	recoverTransCodingTask()
+
+	for i := 0; i < workers; i++ {
+		go transCodingWorker()
+	}
+}
+
+// 添加转码任务，保存当前的编码阶梯，任务恢复时不受之后修改配置的影响
+func EnqueueTransCoding(resourceId uint, quality int, dirName string) error {
+	encoding, err := json.Marshal(GetEncodingConfig())
 	if err != nil {
-		zap.L().Error("音频提取失败" + err.Error())
-		completeTransCoding(resourceId, common.PROCESSING_FAIL)
-		return
+		return err
 	}
 
-	// 生成不同分辨率的MP4
-	videoFiles, err := transcoding.PressingVideo(inputFile, localDir, quality)
+	_, err = InsertTranscodingTask(model.TranscodingTask{
+		Rid:       resourceId,
+		Dir:       dirName,
+		Quality:   quality,
+		Status:    common.TASK_PENDING,
+		NextRunAt: time.Now(),
+		Encoding:  string(encoding),
+	})
 	if err != nil {
-		zap.L().Error("分辨率处理失败" + err.Error())
-		completeTransCoding(resourceId, common.PROCESSING_FAIL)
-		return
+		return err
 	}
 
-	// 生成dash分片
-	err = transcoding.GenerateDash(videoFiles, audioFile, localDir, dirName)
+	notifyTransCodingWorker()
+	return nil
+}
+
+func notifyTransCodingWorker() {
+	select {
+	case transcodingSignal <- struct{}{}:
+	default:
+	}
+}
+
+// 恢复孤立的转码任务
+func recoverTransCodingTask() {
+	// 重启前执行中的任务，从最后完成的步骤继续
+	count, err := ResetRunningTranscodingTask()
 	if err != nil {
-		zap.L().Error("分片生成失败" + err.Error())
-		completeTransCoding(resourceId, common.PROCESSING_FAIL)
-		return
+		zap.L().Error("恢复转码任务失败" + err.Error())
+	} else if count > 0 {
+		zap.L().Info("恢复转码任务" + strconv.FormatInt(count, 10) + "个")
 	}
 
-	// 删除临时文件
-	os.Remove(audioFile)
-	for _, v := range videoFiles {
-		os.Remove(v)
+	// 处于转码中状态但没有转码任务的资源，重新创建任务
+	for _, resource := range SelectOrphanedProcessingResource() {
+		dirName := GetResourceDir(resource)
+		if _, err := os.Stat("./upload/video/" + dirName + "/upload.mp4"); err != nil {
+			zap.L().Error("资源" + convert.UintToString(resource.ID) + "的视频文件不存在，无法恢复转码")
+			completeTransCoding(resource.ID, common.PROCESSING_FAIL)
+			continue
+		}
+
+		if err := EnqueueTransCoding(resource.ID, resource.Quality, dirName); err != nil {
+			zap.L().Error("重新创建转码任务失败" + err.Error())
+		}
 	}
+}
+
+// 转码worker
+func transCodingWorker() {
+	ticker := time.NewTicker(TRANSCODING_POLL_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		// 执行所有可执行的任务
+		for {
+			task := SelectRunnableTranscodingTask()
+			if task.ID == 0 {
+				break
+			}
 
-	// 上传oss
-	if viper.GetString("oss.type") != "local" {
-		if err := UploadVideoToOss(dirName); err != nil {
-			zap.L().Error("视频上传OSS失败" + err.Error())
+			if !ClaimTranscodingTask(task.ID) {
+				// 任务已被其他worker抢占
+				continue
+			}
+
+			runTransCodingTask(task)
+		}
+
+		select {
+		case <-transcodingSignal:
+		case <-ticker.C:
 		}
 	}
+}
+
+// 执行转码任务
+func runTransCodingTask(task model.TranscodingTask) {
+	if err := VideoTransCoding(&task); err != nil {
+		failTransCodingTask(task, err)
+		return
+	}
+
+	UpdateTranscodingTask(task.ID, map[string]interface{}{
+		"status": common.TASK_FINISHED,
+		"error":  "",
+	})
 
 	// 完成转码
-	completeTransCoding(resourceId, common.WAITING_REVIEW)
+	completeTransCoding(task.Rid, common.WAITING_REVIEW)
 }
 
-// 获取宽度支持的最大分辨率
-func getWidthRes(width int) int {
-	//1920*1080
-	if width >= 1920 {
-		return 1080
+// 转码任务失败，未超过最大重试次数则延迟重试
+func failTransCodingTask(task model.TranscodingTask, err error) {
+	maxRetry := viper.GetInt("transcoding.max_retry")
+	if maxRetry <= 0 {
+		maxRetry = DEFAULT_TRANSCODING_MAX_RETRY
 	}
-	// 1280*720
-	if width >= 1280 {
-		return 720
+
+	message := err.Error()
+	if len(message) > 255 {
+		message = message[len(message)-255:]
 	}
-	//720*480
-	if width >= 720 {
-		return 480
+
+	if task.Retry >= maxRetry {
+		zap.L().Error("转码任务" + convert.UintToString(task.ID) + "超过最大重试次数")
+		UpdateTranscodingTask(task.ID, map[string]interface{}{
+			"status": common.TASK_FAILED,
+			"error":  message,
+		})
+		completeTransCoding(task.Rid, common.PROCESSING_FAIL)
+		return
 	}
-	return 360
+
+	UpdateTranscodingTask(task.ID, map[string]interface{}{
+		"status":      common.TASK_PENDING,
+		"retry":       task.Retry + 1,
+		"next_run_at": time.Now().Add(TRANSCODING_RETRY_BACKOFF << task.Retry),
+		"error":       message,
+	})
+}
+
+// 转码(从任务最后完成的步骤继续执行)
+func VideoTransCoding(task *model.TranscodingTask) error {
+	localDir := "./upload/video/" + task.Dir + "/"
+	inputFile := localDir + "upload.mp4"
+	audioFile := localDir + "audio.m4a"
+	encodingConfig, err := getTaskEncodingConfig(task)
+	if err != nil {
+		zap.L().Error("获取任务编码配置失败" + err.Error())
+		return err
+	}
+	profiles := encodingConfig.SelectProfiles(task.Quality)
+	videoFiles := transcoding.PressingOutputs(localDir, profiles)
+	reporter := newTransCodingReporter(task, common.STEP_UPLOAD_OSS, profiles)
+
+	videoData, err := transcoding.GetVideoInfo(inputFile)
+	if err != nil {
+		zap.L().Error("获取视频信息失败" + err.Error())
+		return err
+	}
+
+	// 没有音频轨道的视频跳过音频提取
+	if _, ok := videoData.AudioStream(); !ok {
+		audioFile = ""
+	}
+
+	steps := []struct {
+		step int
+		key  string
+		name string
+		run  func() error
+	}{
+		{common.STEP_NORMALIZE_VIDEO, "normalize_video", "视频标准化", func() error {
+			// 选取音视频流，并将不支持的编码转为h264和aac
+			normalizedFile := localDir + "normalized.mp4"
+			if err := transcoding.NormalizeVideo(inputFile, normalizedFile, videoData, reporter.onProgress); err != nil {
+				return err
+			}
+			return os.Rename(normalizedFile, inputFile)
+		}},
+		{common.STEP_EXTRACT_AUDIO, "extract_audio", "音频提取", func() error {
+			// 提取音频
+			if audioFile == "" {
+				return nil
+			}
+			_, err := transcoding.ExtractingAudio(inputFile, localDir, reporter.onProgress)
+			return err
+		}},
+		{common.STEP_PRESSING_VIDEO, "pressing_video", "分辨率处理", func() error {
+			// 生成不同分辨率的MP4
+			_, err := transcoding.PressingVideo(inputFile, localDir, encodingConfig, task.Quality, videoData, reporter.onProgress)
+			return err
+		}},
+		{common.STEP_GENERATE_DASH, "generate_dash", "分片生成", func() error {
+			// 生成dash分片
+			if err := transcoding.GenerateDash(videoFiles, audioFile, localDir, task.Dir, reporter.onProgress); err != nil {
+				return err
+			}
+
+			// 删除临时文件
+			if audioFile != "" {
+				os.Remove(audioFile)
+			}
+			for _, v := range videoFiles {
+				os.Remove(v)
+			}
+			return nil
+		}},
+		{common.STEP_GENERATE_STORYBOARD, "generate_storyboard", "预览图生成", func() error {
+			// 生成进度条预览图和候选封面，失败时不影响视频播放，记录错误后继续
+			if err := generateVideoPreview(task, inputFile, localDir, videoData, reporter.onProgress); err != nil {
+				zap.L().Error("预览图生成失败" + err.Error())
+			}
+			return nil
+		}},
+		{common.STEP_UPLOAD_OSS, "upload_oss", "视频上传OSS", func() error {
+			// 上传到存储
+			return UploadVideoToOss(task.Dir)
+		}},
+	}
+
+	for _, s := range steps {
+		if s.step <= task.Step {
+			continue
+		}
+
+		reporter.startStep(s.step, s.key)
+		if err := s.run(); err != nil {
+			zap.L().Error(s.name + "失败" + err.Error())
+			return err
+		}
+
+		// 记录检查点
+		task.Step = s.step
+		UpdateTranscodingTaskStep(task.ID, s.step)
+	}
+
+	return nil
+}
+
+// 生成预览图和候选封面，并记录到资源
+func generateVideoPreview(task *model.TranscodingTask, inputFile, localDir string, videoData transcoding.VideoInfoData, onProgress transcoding.ProgressFunc) error {
+	video, _ := videoData.VideoStream()
+	width, height := video.DisplaySize()
+	duration := videoData.GetDuration()
+
+	storyboard, err := transcoding.GenerateStoryboard(inputFile, localDir, duration, width, height, onProgress)
+	if err != nil {
+		return err
+	}
+
+	covers, err := transcoding.GenerateCovers(inputFile, localDir, duration, VIDEO_COVER_CANDIDATES)
+	if err != nil {
+		return err
+	}
+
+	storyboardUrl, err := GenerateFileUrl("video/" + task.Dir + "/" + storyboard)
+	if err != nil {
+		return err
+	}
+
+	coverUrls := make([]string, len(covers))
+	for i, cover := range covers {
+		if coverUrls[i], err = GenerateFileUrl("video/" + task.Dir + "/" + cover); err != nil {
+			return err
+		}
+	}
+
+	return UpdateResourcePreview(task.Rid, storyboardUrl, strings.Join(coverUrls, ","))
 }
 
-// 获取高度支持的最大分辨率
-func getHeigthRes(height int) int {
-	//1920*1080
-	if height >= 1080 {
-		return 1080
+// 获取任务创建时的编码阶梯，旧版本创建的任务没有保存时使用当前配置并保存到任务
+func getTaskEncodingConfig(task *model.TranscodingTask) (transcoding.EncodingConfig, error) {
+	var config transcoding.EncodingConfig
+	if task.Encoding != "" {
+		err := json.Unmarshal([]byte(task.Encoding), &config)
+		return config, err
 	}
-	// 1280*720
-	if height >= 720 {
-		return 720
+
+	config = GetEncodingConfig()
+	encoding, err := json.Marshal(config)
+	if err != nil {
+		return config, err
 	}
-	//720*480
-	if height >= 480 {
-		return 480
+	task.Encoding = string(encoding)
+	return config, UpdateTranscodingTask(task.ID, map[string]interface{}{"encoding": task.Encoding})
+}
+
+// 获取编码阶梯配置，未配置的项使用默认值
+func GetEncodingConfig() transcoding.EncodingConfig {
+	config := transcoding.DefaultEncodingConfig()
+	if viper.IsSet("transcoding.profiles") {
+		var profiles []transcoding.EncodingProfile
+		if err := viper.UnmarshalKey("transcoding.profiles", &profiles); err != nil {
+			zap.L().Error("编码配置解析失败，使用默认配置" + err.Error())
+		} else if len(profiles) > 0 {
+			config.Profiles = profiles
+		}
 	}
-	return 360
+
+	if viper.IsSet("transcoding.keep_aspect_ratio") {
+		config.KeepAspectRatio = viper.GetBool("transcoding.keep_aspect_ratio")
+	}
+
+	if viper.IsSet("transcoding.portrait") {
+		config.Portrait = viper.GetBool("transcoding.portrait")
+	}
+
+	config.Sort()
+	return config
 }
 
 // 完成转码
@@ -121,6 +405,8 @@ func completeTransCoding(resourceId uint, status int) {
 	UpadteResourceStatus(resourceId, status)
 	// 获取资源信息
 	resource := SelectResourceByID(resourceId)
+	// 推送转码结果
+	pushTransCodingResult(resource, status)
 	// 获取转码中资源的数量
 	count := SelectResourceCountByStatus(resource.Vid, common.VIDEO_PROCESSING)
 	// 如果没有转码中的视频，则更新视频为待审核
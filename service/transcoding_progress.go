@@ -0,0 +1,149 @@
+package service
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"clicli/cache"
+	"clicli/common"
+	"clicli/domain/model"
+	"clicli/domain/vo"
+	"clicli/util/transcoding"
+	"clicli/ws"
+)
+
+// 转码进度推送最小间隔
+const TRANSCODING_PROGRESS_INTERVAL = time.Second
+
+// 转码进度上报
+type transCodingReporter struct {
+	uid       uint
+	duration  float64
+	qualities []int
+	lastPush  time.Time
+	progress  vo.TranscodingProgressVO
+}
+
+func newTransCodingReporter(task *model.TranscodingTask, stepCount int, profiles []transcoding.EncodingProfile) *transCodingReporter {
+	qualities := make([]int, len(profiles))
+	for i, p := range profiles {
+		qualities[i] = p.Quality
+	}
+
+	resource := SelectResourceByID(task.Rid)
+	reporter := &transCodingReporter{
+		uid:       resource.Uid,
+		duration:  resource.Duration,
+		qualities: qualities,
+		progress: vo.TranscodingProgressVO{
+			Type:      vo.TRANSCODING_PROGRESS_TYPE,
+			Rid:       resource.ID,
+			Vid:       resource.Vid,
+			Status:    common.VIDEO_PROCESSING,
+			StepCount: stepCount,
+			Eta:       -1,
+		},
+	}
+
+	return reporter
+}
+
+// 开始执行步骤
+func (r *transCodingReporter) startStep(index int, step string) {
+	r.progress.StepIndex = index
+	r.progress.Step = step
+	r.progress.Percent = 0
+	r.progress.Eta = -1
+	r.progress.Renditions = r.renditions(index, 0)
+	r.push(true)
+}
+
+// ffmpeg进度回调
+func (r *transCodingReporter) onProgress(p transcoding.Progress) {
+	percent := 100.0
+	if !p.Finished && r.duration > 0 {
+		percent = math.Min(p.OutTime/r.duration*100, 99.9)
+	}
+
+	eta := -1
+	if p.Speed > 0 && r.duration > p.OutTime {
+		eta = int(math.Ceil((r.duration - p.OutTime) / p.Speed))
+	}
+
+	r.progress.Percent = math.Round(percent*10) / 10
+	r.progress.Eta = eta
+	r.progress.Renditions = r.renditions(r.progress.StepIndex, r.progress.Percent)
+	r.push(p.Finished)
+}
+
+// 各分辨率的进度，所有分辨率在同一个ffmpeg进程中压制，进度一致
+func (r *transCodingReporter) renditions(stepIndex int, percent float64) []vo.RenditionProgressVO {
+	renditions := make([]vo.RenditionProgressVO, len(r.qualities))
+	for i, q := range r.qualities {
+		renditions[i].Quality = q
+		switch {
+		case stepIndex > common.STEP_PRESSING_VIDEO:
+			renditions[i].Percent = 100
+		case stepIndex == common.STEP_PRESSING_VIDEO:
+			renditions[i].Percent = percent
+		}
+	}
+
+	return renditions
+}
+
+// 推送进度，非强制推送时限制推送频率
+func (r *transCodingReporter) push(force bool) {
+	if !force && time.Since(r.lastPush) < TRANSCODING_PROGRESS_INTERVAL {
+		return
+	}
+	r.lastPush = time.Now()
+
+	sendTransCodingProgress(r.uid, r.progress)
+}
+
+// 推送转码结果
+func pushTransCodingResult(resource model.Resource, status int) {
+	progress := cache.GetTranscodingProgress(resource.ID)
+	progress.Type = vo.TRANSCODING_PROGRESS_TYPE
+	progress.Rid = resource.ID
+	progress.Vid = resource.Vid
+	progress.Status = status
+	progress.Eta = 0
+	if status != common.PROCESSING_FAIL {
+		progress.StepIndex = progress.StepCount
+		progress.Percent = 100
+	}
+
+	sendTransCodingProgress(resource.Uid, progress)
+}
+
+func sendTransCodingProgress(userId uint, progress vo.TranscodingProgressVO) {
+	cache.SetTranscodingProgress(progress)
+
+	// 推送给上传者
+	data, _ := json.Marshal(&progress)
+	ws.SendMsg(userId, data)
+}
+
+// 获取转码进度
+func GetTranscodingProgress(resource model.Resource) vo.TranscodingProgressVO {
+	progress := cache.GetTranscodingProgress(resource.ID)
+	if progress.Rid != 0 {
+		return progress
+	}
+
+	// 缓存不存在时通过转码任务生成
+	task := SelectTranscodingTaskByResource(resource.ID)
+	return vo.TranscodingProgressVO{
+		Type:       vo.TRANSCODING_PROGRESS_TYPE,
+		Rid:        resource.ID,
+		Vid:        resource.Vid,
+		Status:     resource.Status,
+		StepIndex:  task.Step,
+		StepCount:  common.STEP_UPLOAD_OSS,
+		Eta:        -1,
+		Renditions: []vo.RenditionProgressVO{},
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"time"
+
+	"clicli/common"
+	"clicli/domain/model"
+)
+
+func InsertTranscodingTask(task model.TranscodingTask) (uint, error) {
+	if err := mysqlClient.Create(&task).Error; err != nil {
+		return 0, err
+	}
+
+	return task.ID, nil
+}
+
+// 查询一个可以执行的转码任务
+func SelectRunnableTranscodingTask() (task model.TranscodingTask) {
+	mysqlClient.Where("status = ? and next_run_at <= ?", common.TASK_PENDING, time.Now()).
+		Order("next_run_at").First(&task)
+	return
+}
+
+// 抢占转码任务，防止多个worker执行同一个任务
+func ClaimTranscodingTask(taskId uint) bool {
+	result := mysqlClient.Model(&model.TranscodingTask{}).
+		Where("id = ? and status = ?", taskId, common.TASK_PENDING).
+		Update("status", common.TASK_RUNNING)
+
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+// 通过资源ID查询转码任务
+func SelectTranscodingTaskByResource(resourceId uint) (task model.TranscodingTask) {
+	mysqlClient.Where("rid = ?", resourceId).Last(&task)
+	return
+}
+
+// 更新转码任务已完成的步骤
+func UpdateTranscodingTaskStep(taskId uint, step int) error {
+	return mysqlClient.Model(&model.TranscodingTask{}).Where("id = ?", taskId).Update("step", step).Error
+}
+
+// 更新转码任务状态
+func UpdateTranscodingTask(taskId uint, updates map[string]interface{}) error {
+	return mysqlClient.Model(&model.TranscodingTask{}).Where("id = ?", taskId).Updates(updates).Error
+}
+
+// 将执行中的任务重置为等待执行(服务重启后这些任务已没有worker在执行)
+func ResetRunningTranscodingTask() (int64, error) {
+	result := mysqlClient.Model(&model.TranscodingTask{}).Where("status = ?", common.TASK_RUNNING).
+		Updates(map[string]interface{}{
+			"status":      common.TASK_PENDING,
+			"next_run_at": time.Now(),
+		})
+
+	return result.RowsAffected, result.Error
+}
+
+// 查询转码中但没有转码任务的资源
+func SelectOrphanedProcessingResource() (resources []model.Resource) {
+	tasks := mysqlClient.Model(&model.TranscodingTask{}).Select("rid")
+	mysqlClient.Where("status = ? and id not in (?)", common.VIDEO_PROCESSING, tasks).Find(&resources)
+	return
+}
@@ -1,48 +1,35 @@
 package service
 
 import (
-	"go.uber.org/zap"
+	"errors"
 	"os"
+	"path"
 	"strconv"
+	"strings"
 	"time"
 
+	"clicli/domain/model"
+	"clicli/storage"
 	"clicli/util/random"
-	"github.com/spf13/viper"
-	"github.com/wangzmgit/unioss"
 )
 
-// 上传图片到OSS
-func UploadImgToOss(fileName string) error {
-	objectKey := "image/" + fileName
-	filePath := "./upload/" + objectKey
-	oss, err := unioss.GetStorage()
-	if err != nil {
-		return err
-	}
-	zap.L().Error("上传图片")
-	return oss.PutObjectFromFile(objectKey, filePath)
-}
-
-// 上传视频到OSS
+// 上传视频文件夹到存储(原始文件只保存在本地)
 func UploadVideoToOss(dirName string) error {
-	oss, err := unioss.GetStorage()
-	if err != nil {
-		return err
-	}
-
 	files, err := os.ReadDir("./upload/video/" + dirName)
 	if err != nil {
 		return err
 	}
 
 	for _, f := range files {
-		if f.Name() == "upload.mp4" && !viper.GetBool("file.video_adaptation_ios") {
+		if f.IsDir() || f.Name() == "upload.mp4" {
 			continue
 		}
 
 		objectKey := "video/" + dirName + "/" + f.Name()
 		filePath := "./upload/" + objectKey
-		oss.PutObjectFromFile(objectKey, filePath)
+		if err := storage.GetStorage().PutObjectFromFile(objectKey, filePath); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -66,16 +53,54 @@ func GenerateVideoFilename() string {
 	return "v_" + strconv.FormatInt(time.Now().UnixNano(), 36) + random.GenerateNumberCode(3)
 }
 
+/**
+ * 随机生成字幕文件名
+ * return: 文件名字符串
+ */
+func GenerateSubtitleFilename() string {
+	// 前缀 + 时间戳(36进制) + 3位随机数 + 后缀
+	return "sub_" + strconv.FormatInt(time.Now().UnixNano(), 36) + random.GenerateNumberCode(3) + ".vtt"
+}
+
 // 生成文件url
 func GenerateFileUrl(objectKey string) (string, error) {
-	if viper.GetString("oss.type") != "local" {
-		oss, err := unioss.GetStorage()
+	return storage.GetStorage().GetObjectUrl(objectKey), nil
+}
+
+// 使用自动生成的候选封面，复制到图片目录并返回新的图片链接
+func UseGeneratedCover(videoId uint, coverUrl string) (string, error) {
+	for _, resource := range SelectResourceByVideo(videoId, false) {
+		if !containsCover(resource, coverUrl) {
+			continue
+		}
+
+		cover, err := storage.GetStorage().GetObject("video/" + GetResourceDir(resource) + "/" + path.Base(coverUrl))
 		if err != nil {
 			return "", err
 		}
+		defer cover.Close()
 
-		return oss.GetObjectUrl(objectKey), nil
+		fileName := GenerateImgFilename(".jpg")
+		if err := storage.GetStorage().PutObject("image/"+fileName, cover); err != nil {
+			return "", err
+		}
+
+		return GenerateFileUrl("image/" + fileName)
 	}
 
-	return "/api/" + objectKey, nil
+	return "", errors.New("cover not found")
+}
+
+// 候选封面中是否包含该链接(原始链接或签名链接)
+func containsCover(resource model.Resource, coverUrl string) bool {
+	if resource.Covers == "" || path.Base(path.Dir(coverUrl)) != GetResourceDir(resource) {
+		return false
+	}
+
+	for _, cover := range strings.Split(resource.Covers, ",") {
+		if path.Base(cover) == path.Base(coverUrl) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"clicli/cache"
+	"clicli/domain/model"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 分片临时存储目录
+const CHUNK_DIR = "./upload/chunk/"
+
+// 默认分片大小 n MB
+const DEFAULT_CHUNK_SIZE = 5
+
+var (
+	ErrChunkIndex = errors.New("chunk index out of range")
+	ErrChunkSize  = errors.New("chunk size mismatch")
+	ErrChunkHash  = errors.New("chunk checksum mismatch")
+	ErrChunkLost  = errors.New("chunk missing")
+)
+
+// 创建分片上传会话
+func CreateUploadSession(vid, uid uint, filename string, size int64) (model.UploadSession, error) {
+	chunkSize := viper.GetInt64("file.chunk_size")
+	if chunkSize <= 0 {
+		chunkSize = DEFAULT_CHUNK_SIZE
+	}
+	chunkSize *= 1024 * 1024
+
+	session := model.UploadSession{
+		ID:         GenerateVideoFilename(),
+		Vid:        vid,
+		Uid:        uid,
+		Filename:   filename,
+		Size:       size,
+		ChunkSize:  chunkSize,
+		ChunkCount: int((size + chunkSize - 1) / chunkSize),
+	}
+
+	if err := os.MkdirAll(CHUNK_DIR+session.ID, os.ModePerm); err != nil {
+		return session, err
+	}
+
+	cache.SetUploadSession(session)
+	return session, nil
+}
+
+// 获取分片上传会话
+func GetUploadSession(id string) model.UploadSession {
+	return cache.GetUploadSession(id)
+}
+
+// 保存分片，校验分片大小和sha256
+func SaveUploadChunk(session model.UploadSession, index int, hash string, chunk io.Reader) error {
+	if index < 0 || index >= session.ChunkCount {
+		return ErrChunkIndex
+	}
+
+	// 最后一个分片可能小于分片大小
+	expected := session.ChunkSize
+	if index == session.ChunkCount-1 {
+		expected = session.Size - session.ChunkSize*int64(session.ChunkCount-1)
+	}
+
+	chunkPath := CHUNK_DIR + session.ID + "/" + strconv.Itoa(index)
+	tmp, err := os.CreateTemp(CHUNK_DIR+session.ID, "tmp_")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	// 最多读取 expected + 1 字节，以实际字节数为准
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(chunk, expected+1))
+	tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	if n != expected {
+		return ErrChunkSize
+	}
+
+	if hex.EncodeToString(h.Sum(nil)) != hash {
+		return ErrChunkHash
+	}
+
+	if err := os.Rename(tmp.Name(), chunkPath); err != nil {
+		return err
+	}
+
+	cache.RefreshUploadSession(session.ID)
+	return nil
+}
+
+// 获取已上传的分片序号
+func SelectUploadedChunks(session model.UploadSession) []int {
+	uploaded := make([]int, 0)
+	files, err := os.ReadDir(CHUNK_DIR + session.ID)
+	if err != nil {
+		return uploaded
+	}
+
+	for _, f := range files {
+		index, err := strconv.Atoi(f.Name())
+		if err != nil || index < 0 || index >= session.ChunkCount {
+			continue
+		}
+		uploaded = append(uploaded, index)
+	}
+
+	sort.Ints(uploaded)
+	return uploaded
+}
+
+// 合并分片到视频文件夹，返回文件夹名
+func MergeUploadChunks(session model.UploadSession) (string, error) {
+	chunkDir := CHUNK_DIR + session.ID + "/"
+	for i := 0; i < session.ChunkCount; i++ {
+		if _, err := os.Stat(chunkDir + strconv.Itoa(i)); err != nil {
+			return "", ErrChunkLost
+		}
+	}
+
+	videoDir := "./upload/video/" + session.ID
+	if err := os.Mkdir(videoDir, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	if err := concatChunks(chunkDir, session, videoDir+"/upload.mp4"); err != nil {
+		os.RemoveAll(videoDir)
+		return "", err
+	}
+
+	// 合并完成后删除会话和分片
+	cache.DelUploadSession(session.ID)
+	os.RemoveAll(CHUNK_DIR + session.ID)
+
+	return session.ID, nil
+}
+
+func concatChunks(chunkDir string, session model.UploadSession, output string) error {
+	out, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	var total int64
+	for i := 0; i < session.ChunkCount; i++ {
+		chunk, err := os.Open(chunkDir + strconv.Itoa(i))
+		if err != nil {
+			return err
+		}
+
+		n, err := io.Copy(out, chunk)
+		chunk.Close()
+		if err != nil {
+			return err
+		}
+		total += n
+	}
+
+	if total != session.Size {
+		return ErrChunkSize
+	}
+	return nil
+}
+
+// 清理过期的分片上传会话
+func CleanExpiredUploadSession() {
+	dirs, err := os.ReadDir(CHUNK_DIR)
+	if err != nil {
+		return
+	}
+
+	for _, d := range dirs {
+		info, err := d.Info()
+		if err != nil || !d.IsDir() {
+			continue
+		}
+
+		// 会话已过期且超过过期时间没有新的分片
+		if cache.IsUploadSessionExists(d.Name()) ||
+			time.Since(info.ModTime()) < time.Hour*cache.UPLOAD_SESSION_EXPRIRATION_TIME {
+			continue
+		}
+
+		if err := os.RemoveAll(CHUNK_DIR + d.Name()); err != nil {
+			zap.L().Error("清理分片失败" + err.Error())
+			continue
+		}
+		zap.L().Info("清理过期分片上传会话:" + d.Name())
+	}
+}
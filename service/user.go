@@ -12,6 +12,7 @@ import (
 
 func InsertUser(user model.User) {
 	mysqlClient.Create(&user)
+	IndexUser(user.ID)
 }
 
 // 获取用户信息 (通过邮箱，只查数据库)
@@ -77,6 +78,8 @@ func UpdateUserInfo(userId uint, modifyDTO dto.ModifyUserInfoDTO) error {
 	).Error; err != nil {
 		return err
 	}
+	// 更新搜索索引
+	IndexUser(userId)
 	return nil
 }
 
@@ -104,6 +107,8 @@ func AdminUpdateUserInfo(modifyDTO dto.AdminModifyUserInfoDTO) error {
 	).Error; err != nil {
 		return err
 	}
+	// 更新搜索索引
+	IndexUser(modifyDTO.ID)
 	return nil
 }
 
@@ -151,6 +156,7 @@ func UpdateUserPwd(modifyDTO dto.ModifyPwdDTO) error {
 func DeleteUser(id uint) {
 	cache.DelUser(id)
 	mysqlClient.Where("id = ?", id).Delete(&model.User{})
+	IndexUser(id)
 }
 
 // 用户总数量
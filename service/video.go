@@ -5,6 +5,7 @@ import (
 
 	"clicli/cache"
 	"clicli/common"
+	"clicli/db/mysql"
 	"clicli/domain/dto"
 	"clicli/domain/model"
 	"clicli/domain/vo"
@@ -46,7 +47,7 @@ func GetVideoInfo(videoId uint) (video model.Video) {
 // 通过分区查询视频列表(通过审核)
 func SelectVideoListByPartition(partitionId uint, page, pageSize int) (total int64, videos []model.Video) {
 	partitionIds := mysqlClient.Model(&model.Partition{}).Select("id").Where("parent_id = ?", partitionId)
-	client := mysqlClient.Where("status = ? and partition_id in (?)", common.AUDIT_APPROVED, partitionIds)
+	client := mysqlClient.Scopes(mysql.ExcludeLegacyLive).Where("status = ? and partition_id in (?)", common.AUDIT_APPROVED, partitionIds)
 	client.Model(&model.Video{}).Count(&total)
 	client.Limit(pageSize).Offset((page - 1) * pageSize).Find(&videos)
 	return
@@ -54,30 +55,7 @@ func SelectVideoListByPartition(partitionId uint, page, pageSize int) (total int
 
 // 通过子分区查询视频列表(通过审核)
 func SelectVideoListBySubpartition(partitionId uint, page, pageSize int) (total int64, videos []model.Video) {
-	client := mysqlClient.Where("status = ? and partition_id = ?", common.AUDIT_APPROVED, partitionId)
-	client.Model(&model.Video{}).Count(&total)
-	client.Limit(pageSize).Offset((page - 1) * pageSize).Find(&videos)
-	return
-}
-
-// 通过关键词查询视频
-func SelectVideoListByKeywords(keywords string, page, pageSize int) (videos []model.Video) {
-	mysqlClient.Where("status = ? and title like ?", common.AUDIT_APPROVED, "%"+keywords+"%").
-		Limit(pageSize).Offset((page - 1) * pageSize).Find(&videos)
-
-	return
-}
-
-func UpdateVideoFlv(vi model.Video) {
-	mysqlClient.Model(&model.Video{}).Where("id = ?", vi.ID).Update("flv", vi.Flv)
-
-	cache.DelVideo(vi.ID)
-
-	return
-}
-
-func SelectLiveListByStatus(page, pageSize, status int) (total int64, videos []model.Video) {
-	client := mysqlClient.Where("status = ?", status).Where("video = ?", 0)
+	client := mysqlClient.Scopes(mysql.ExcludeLegacyLive).Where("status = ? and partition_id = ?", common.AUDIT_APPROVED, partitionId)
 	client.Model(&model.Video{}).Count(&total)
 	client.Limit(pageSize).Offset((page - 1) * pageSize).Find(&videos)
 	return
@@ -85,29 +63,15 @@ func SelectLiveListByStatus(page, pageSize, status int) (total int64, videos []m
 
 // 通过视频状态查询视频列表
 func SelectVideoListByStatus(page, pageSize, status int) (total int64, videos []model.Video) {
-	client := mysqlClient.Where("status = ?", status)
+	client := mysqlClient.Scopes(mysql.ExcludeLegacyLive).Where("status = ?", status)
 	client.Model(&model.Video{}).Count(&total)
 	client.Limit(pageSize).Offset((page - 1) * pageSize).Find(&videos)
 	return
 }
 
-// 管理员通过关键词查询视频
-func AdminSelectVideoListByKeywords(keywords string, page, pageSize int) (total int64, videos []model.Video) {
-	search := mysqlClient.Where("status = ? and title like ?", common.AUDIT_APPROVED, "%"+keywords+"%")
-	search.Model(&model.Video{}).Count(&total)
-	search.Limit(pageSize).Offset((page - 1) * pageSize).Find(&videos)
-
-	return
-}
-
-func SelectLikeListByClicks(pageSize int) (videos []model.Video) {
-	mysqlClient.Model(model.Video{}).Where("status = ?", common.AUDIT_APPROVED).Where("video = ?", 0).Limit(pageSize).Order("clicks desc").Find(&videos)
-	return
-}
-
 // 查询点击量高的视频
 func SelectVideoListByClicks(pageSize int) (videos []model.Video) {
-	mysqlClient.Debug().Where("status = ?", common.AUDIT_APPROVED).Where("video = ?", 1).Limit(pageSize).Order("clicks desc").Find(&videos)
+	mysqlClient.Scopes(mysql.ExcludeLegacyLive).Where("status = ?", common.AUDIT_APPROVED).Limit(pageSize).Order("clicks desc").Find(&videos)
 	return
 }
 
@@ -126,8 +90,8 @@ func SelectVideoAuthorId(videoId uint) (userId uint) {
 // 查询用户视频
 func SelectVideoByUserId(userId uint, page, pageSize int) (total int64, videos []model.Video) {
 	offset := (page - 1) * pageSize
-	mysqlClient.Model(&model.Video{}).Where("uid = ? and status = ?", userId, common.AUDIT_APPROVED).Count(&total)
-	mysqlClient.Where("uid = ? and status = ?", userId, common.AUDIT_APPROVED).Limit(pageSize).Offset(offset).Find(&videos)
+	mysqlClient.Scopes(mysql.ExcludeLegacyLive).Model(&model.Video{}).Where("uid = ? and status = ?", userId, common.AUDIT_APPROVED).Count(&total)
+	mysqlClient.Scopes(mysql.ExcludeLegacyLive).Where("uid = ? and status = ?", userId, common.AUDIT_APPROVED).Limit(pageSize).Offset(offset).Find(&videos)
 	return
 }
 
@@ -155,6 +119,9 @@ func UpdateVideoInfo(modifyDTO dto.ModifyVideoDTO) error {
 
 	// 移除缓存
 	cache.DelVideo(modifyDTO.VID)
+	cache.DelRelatedVideo(modifyDTO.VID)
+	// 修改后需要重新审核，从搜索索引中移除
+	IndexVideo(modifyDTO.VID)
 
 	return nil
 }
@@ -175,8 +142,11 @@ func UpadteVideoStatus(videoId uint, status int) error {
 		return err
 	}
 
-	// 移除缓存
+	// 移除缓存，重新审核后相关视频需要重新计算
 	cache.DelVideo(videoId)
+	cache.DelRelatedVideo(videoId)
+	// 更新搜索索引
+	IndexVideo(videoId)
 
 	return nil
 }
@@ -184,7 +154,9 @@ func UpadteVideoStatus(videoId uint, status int) error {
 // 删除视频
 func DeleteVideo(id uint) {
 	cache.DelVideo(id)
+	cache.DelRelatedVideo(id)
 	mysqlClient.Where("id = ?", id).Delete(&model.Video{})
+	IndexVideo(id)
 }
 
 // 视频是否属于用户
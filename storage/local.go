@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 本地存储根目录
+const LOCAL_ROOT = "./upload"
+
+// 本地存储访问路径前缀
+const LOCAL_URL_PREFIX = "/api/"
+
+type localStorage struct {
+	root      string
+	urlPrefix string
+}
+
+func NewLocal(root, urlPrefix string) Storage {
+	return &localStorage{root: root, urlPrefix: urlPrefix}
+}
+
+func (l *localStorage) path(objectKey string) string {
+	return filepath.Join(l.root, filepath.FromSlash(cleanKey(objectKey)))
+}
+
+func (l *localStorage) PutObject(objectKey string, reader io.Reader) error {
+	target := l.path(objectKey)
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+
+	// 先写入临时文件再重命名，避免读到不完整的文件
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".tmp_")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, reader); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), target)
+}
+
+func (l *localStorage) PutObjectFromFile(objectKey, filePath string) error {
+	// 文件已经在存储目录中
+	if filepath.Clean(filePath) == l.path(objectKey) {
+		return nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return l.PutObject(objectKey, file)
+}
+
+func (l *localStorage) GetObject(objectKey string) (io.ReadCloser, error) {
+	return os.Open(l.path(objectKey))
+}
+
+func (l *localStorage) DeleteObject(objectKey string) error {
+	target := l.path(objectKey)
+	err := os.Remove(target)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// 删除空的上级目录(不包括根目录和一级目录)
+	root := filepath.Clean(l.root)
+	for dir := filepath.Dir(target); filepath.Dir(dir) != root && dir != root; dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (l *localStorage) GetObjectUrl(objectKey string) string {
+	return l.urlPrefix + cleanKey(objectKey)
+}
+
+// 本地存储直接返回访问链接
+func (l *localStorage) SignUrl(objectKey string, expires time.Duration) (string, error) {
+	return l.GetObjectUrl(objectKey), nil
+}
+
+func (l *localStorage) ListObjects(prefix string) ([]ObjectInfo, error) {
+	prefix = cleanPrefix(prefix)
+	// 从前缀所在的目录开始遍历
+	dir := l.root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = filepath.Join(l.root, filepath.FromSlash(prefix[:i]))
+	}
+
+	objects := make([]ObjectInfo, 0)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, ObjectInfo{Key: key, Size: info.Size(), LastModified: info.ModTime()})
+		return nil
+	})
+
+	return objects, err
+}
+
+// 规范化对象key，去掉开头的斜杠并防止路径穿越
+func cleanKey(objectKey string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(objectKey)), "/")
+}
+
+// 规范化前缀，保留结尾的斜杠
+func cleanPrefix(prefix string) string {
+	clean := cleanKey(prefix)
+	if clean != "" && strings.HasSuffix(prefix, "/") {
+		clean += "/"
+	}
+	return clean
+}
@@ -0,0 +1,336 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// s3签名算法
+const S3_ALGORITHM = "AWS4-HMAC-SHA256"
+
+// 不对请求体计算哈希
+const S3_UNSIGNED_PAYLOAD = "UNSIGNED-PAYLOAD"
+
+// s3默认区域
+const S3_DEFAULT_REGION = "us-east-1"
+
+// s3兼容存储(AWS S3、MinIO等)，使用SigV4签名
+type s3Storage struct {
+	config   Config
+	endpoint *url.URL
+	client   *http.Client
+	// 签名时间，便于测试
+	now func() time.Time
+}
+
+func NewS3(config Config) (Storage, error) {
+	if config.KeyID == "" || config.KeySecret == "" || config.Bucket == "" || config.Endpoint == "" {
+		return nil, errors.New("configuration not correct")
+	}
+
+	endpoint := config.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Region == "" {
+		config.Region = S3_DEFAULT_REGION
+	}
+
+	return &s3Storage{
+		config:   config,
+		endpoint: u,
+		client:   &http.Client{Timeout: 10 * time.Minute},
+		now:      time.Now,
+	}, nil
+}
+
+// 对象的请求地址
+func (s *s3Storage) objectUrl(objectKey string) *url.URL {
+	u := *s.endpoint
+	key := cleanKey(objectKey)
+	if s.config.PathStyle {
+		u.Path = "/" + s.config.Bucket + "/" + key
+		u.RawPath = "/" + s.config.Bucket + "/" + encodePath(key)
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+		u.Path = "/" + key
+		u.RawPath = "/" + encodePath(key)
+	}
+	return &u
+}
+
+func (s *s3Storage) PutObject(objectKey string, reader io.Reader) error {
+	// s3不支持分块传输编码，需要先确定内容长度
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, s.objectUrl(objectKey).String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	_, err = s.do(req)
+	return err
+}
+
+func (s *s3Storage) PutObjectFromFile(objectKey, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, s.objectUrl(objectKey).String(), file)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	_, err = s.do(req)
+	return err
+}
+
+func (s *s3Storage) GetObject(objectKey string) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, s.objectUrl(objectKey).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.send(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, responseError(res)
+	}
+	return res.Body, nil
+}
+
+func (s *s3Storage) DeleteObject(objectKey string) error {
+	req, err := http.NewRequest(http.MethodDelete, s.objectUrl(objectKey).String(), nil)
+	if err != nil {
+		return err
+	}
+	_, err = s.do(req)
+	return err
+}
+
+func (s *s3Storage) GetObjectUrl(objectKey string) string {
+	if s.config.Domain != "" {
+		return strings.TrimSuffix(s.config.Domain, "/") + "/" + encodePath(cleanKey(objectKey))
+	}
+	return s.objectUrl(objectKey).String()
+}
+
+// 生成预签名的GET链接
+func (s *s3Storage) SignUrl(objectKey string, expires time.Duration) (string, error) {
+	u := s.objectUrl(objectKey)
+	t := s.now().UTC()
+	date := t.Format("20060102")
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", S3_ALGORITHM)
+	query.Set("X-Amz-Credential", s.config.KeyID+"/"+s.scope(date))
+	query.Set("X-Amz-Date", t.Format("20060102T150405Z"))
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		canonicalQuery(query),
+		"host:" + u.Host + "\n",
+		"host",
+		S3_UNSIGNED_PAYLOAD,
+	}, "\n")
+
+	query.Set("X-Amz-Signature", s.signature(t, canonicalRequest))
+	u.RawQuery = canonicalQuery(query)
+	return u.String(), nil
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+func (s *s3Storage) ListObjects(prefix string) ([]ObjectInfo, error) {
+	objects := make([]ObjectInfo, 0)
+	token := ""
+	for {
+		u := s.objectUrl("")
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", cleanPrefix(prefix))
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		u.RawQuery = canonicalQuery(query)
+
+		req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := s.do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var result listBucketResult
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, err
+		}
+
+		for _, c := range result.Contents {
+			objects = append(objects, ObjectInfo{Key: c.Key, Size: c.Size, LastModified: c.LastModified})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// 发送请求并读取响应
+func (s *s3Storage) do(req *http.Request) ([]byte, error) {
+	res, err := s.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, responseError(res)
+	}
+	return io.ReadAll(res.Body)
+}
+
+// 签名并发送请求
+func (s *s3Storage) send(req *http.Request) (*http.Response, error) {
+	t := s.now().UTC()
+	req.Header.Set("X-Amz-Date", t.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", S3_UNSIGNED_PAYLOAD)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + S3_UNSIGNED_PAYLOAD + "\n" +
+		"x-amz-date:" + t.Format("20060102T150405Z") + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		S3_UNSIGNED_PAYLOAD,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		S3_ALGORITHM, s.config.KeyID, s.scope(t.Format("20060102")), signedHeaders, s.signature(t, canonicalRequest)))
+
+	return s.client.Do(req)
+}
+
+func (s *s3Storage) scope(date string) string {
+	return date + "/" + s.config.Region + "/s3/aws4_request"
+}
+
+// 计算SigV4签名
+func (s *s3Storage) signature(t time.Time, canonicalRequest string) string {
+	date := t.Format("20060102")
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		S3_ALGORITHM,
+		t.Format("20060102T150405Z"),
+		s.scope(date),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.KeySecret), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// 按key排序并编码查询参数
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			pairs = append(pairs, encodeURIComponent(k)+"="+encodeURIComponent(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// 对路径中的每一段进行编码
+func encodePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = encodeURIComponent(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// RFC3986编码，只保留非保留字符
+func encodeURIComponent(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func responseError(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("s3 request failed: %s %s", res.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// 存储类型
+const (
+	LOCAL   = "local"
+	S3      = "s3"
+	MINIO   = "minio"
+	ALIYUN  = "aliyun"
+	TENCENT = "tencent"
+	QINIU   = "qiniu"
+)
+
+var ErrNotSupported = errors.New("operation not supported by storage driver")
+
+// 存储接口，所有文件的上传、删除和链接生成都通过该接口
+type Storage interface {
+	// 上传对象
+	PutObject(objectKey string, reader io.Reader) error
+	// 上传本地文件
+	PutObjectFromFile(objectKey, filePath string) error
+	// 获取对象内容，使用后需要关闭
+	GetObject(objectKey string) (io.ReadCloser, error)
+	// 删除对象
+	DeleteObject(objectKey string) error
+	// 获取对象访问链接
+	GetObjectUrl(objectKey string) string
+	// 获取带有效期的签名链接
+	SignUrl(objectKey string, expires time.Duration) (string, error)
+	// 列出前缀下的所有对象
+	ListObjects(prefix string) ([]ObjectInfo, error)
+}
+
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+type Config struct {
+	KeyID     string
+	KeySecret string
+	Bucket    string
+	Endpoint  string
+	AppID     string
+	Region    string
+	Domain    string
+	Private   bool
+	PathStyle bool // s3使用路径风格访问(MinIO)
+}
+
+var (
+	mu             sync.RWMutex
+	currentStorage Storage = NewLocal(LOCAL_ROOT, LOCAL_URL_PREFIX)
+)
+
+// 根据类型创建存储并设置为当前存储
+func NewStorage(storageType string, config Config) error {
+	var s Storage
+	var err error
+	switch storageType {
+	case LOCAL, "":
+		s = NewLocal(LOCAL_ROOT, LOCAL_URL_PREFIX)
+	case S3, MINIO:
+		s, err = NewS3(config)
+	case ALIYUN, TENCENT, QINIU:
+		s, err = newUnioss(storageType, config)
+	default:
+		err = errors.New("driver not exists")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	currentStorage = s
+	mu.Unlock()
+	return nil
+}
+
+// 获取当前存储
+func GetStorage() Storage {
+	mu.RLock()
+	defer mu.RUnlock()
+	return currentStorage
+}
+
+// 是否为本地存储
+func IsLocal(s Storage) bool {
+	_, ok := s.(*localStorage)
+	return ok
+}
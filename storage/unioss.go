@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/wangzmgit/unioss"
+)
+
+// 通过unioss接入的云存储(阿里云、腾讯云、七牛云)
+type uniossStorage struct {
+	oss  unioss.Storage
+	list listFunc
+	sign signFunc
+}
+
+func newUnioss(storageType string, config Config) (Storage, error) {
+	err := unioss.NewStorage(storageType, unioss.Config{
+		KeyID:     config.KeyID,
+		KeySecret: config.KeySecret,
+		Bucket:    config.Bucket,
+		Endpoint:  config.Endpoint,
+		AppID:     config.AppID,
+		Region:    config.Region,
+		Domain:    config.Domain,
+		Private:   config.Private,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	oss, err := unioss.GetStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	list, sign, err := newUniossClient(storageType, config)
+	if err != nil {
+		return nil, err
+	}
+	return &uniossStorage{oss: oss, list: list, sign: sign}, nil
+}
+
+func (u *uniossStorage) PutObject(objectKey string, reader io.Reader) error {
+	return u.oss.PutObject(cleanKey(objectKey), reader)
+}
+
+func (u *uniossStorage) PutObjectFromFile(objectKey, filePath string) error {
+	return u.oss.PutObjectFromFile(cleanKey(objectKey), filePath)
+}
+
+// unioss只支持下载到文件，下载到临时文件后读取
+func (u *uniossStorage) GetObject(objectKey string) (io.ReadCloser, error) {
+	tmp, err := os.CreateTemp("", "oss_")
+	if err != nil {
+		return nil, err
+	}
+	tmp.Close()
+
+	if err := u.oss.GetObjectToFile(cleanKey(objectKey), tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	file, err := os.Open(tmp.Name())
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return &tempFile{File: file}, nil
+}
+
+func (u *uniossStorage) DeleteObject(objectKey string) error {
+	return u.oss.DeleteObject(cleanKey(objectKey))
+}
+
+func (u *uniossStorage) GetObjectUrl(objectKey string) string {
+	return u.oss.GetObjectUrl(cleanKey(objectKey))
+}
+
+func (u *uniossStorage) SignUrl(objectKey string, expires time.Duration) (string, error) {
+	return u.sign(objectKey, expires)
+}
+
+func (u *uniossStorage) ListObjects(prefix string) ([]ObjectInfo, error) {
+	return u.list(prefix)
+}
+
+// 关闭时删除的临时文件
+type tempFile struct {
+	*os.File
+}
+
+func (t *tempFile) Close() error {
+	err := t.File.Close()
+	os.Remove(t.File.Name())
+	return err
+}
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/qiniu/go-sdk/v7/auth"
+	"github.com/qiniu/go-sdk/v7/auth/qbox"
+	qiniu "github.com/qiniu/go-sdk/v7/storage"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// 每次请求列出的最大数量
+const LIST_MAX_KEYS = 1000
+
+type listFunc func(prefix string) ([]ObjectInfo, error)
+
+type signFunc func(objectKey string, expires time.Duration) (string, error)
+
+// unioss没有列出对象和生成预签名链接的接口，使用各云存储的SDK实现
+func newUniossClient(storageType string, config Config) (listFunc, signFunc, error) {
+	switch storageType {
+	case ALIYUN:
+		client, err := oss.New(config.Endpoint, config.KeyID, config.KeySecret)
+		if err != nil {
+			return nil, nil, err
+		}
+		bucket, err := client.Bucket(config.Bucket)
+		if err != nil {
+			return nil, nil, err
+		}
+		return aliyunLister(bucket), aliyunSigner(bucket), nil
+	case TENCENT:
+		bucketUrl, err := url.Parse(fmt.Sprintf("https://%s-%s.cos.%s.myqcloud.com", config.Bucket, config.AppID, config.Region))
+		if err != nil {
+			return nil, nil, err
+		}
+		client := cos.NewClient(&cos.BaseURL{BucketURL: bucketUrl}, &http.Client{
+			Transport: &cos.AuthorizationTransport{SecretID: config.KeyID, SecretKey: config.KeySecret},
+		})
+		return tencentLister(client), tencentSigner(client, config), nil
+	case QINIU:
+		mac := qbox.NewMac(config.KeyID, config.KeySecret)
+		manager := qiniu.NewBucketManager((*auth.Credentials)(mac), &qiniu.Config{UseHTTPS: true})
+		return qiniuLister(manager, config.Bucket), qiniuSigner(mac, config), nil
+	}
+	return nil, nil, ErrNotSupported
+}
+
+func aliyunLister(bucket *oss.Bucket) listFunc {
+	return func(prefix string) ([]ObjectInfo, error) {
+		objects := make([]ObjectInfo, 0)
+		token := ""
+		for {
+			options := []oss.Option{oss.Prefix(cleanPrefix(prefix)), oss.MaxKeys(LIST_MAX_KEYS)}
+			if token != "" {
+				options = append(options, oss.ContinuationToken(token))
+			}
+
+			result, err := bucket.ListObjectsV2(options...)
+			if err != nil {
+				return nil, err
+			}
+			for _, object := range result.Objects {
+				objects = append(objects, ObjectInfo{Key: object.Key, Size: object.Size, LastModified: object.LastModified})
+			}
+
+			if !result.IsTruncated || result.NextContinuationToken == "" {
+				return objects, nil
+			}
+			token = result.NextContinuationToken
+		}
+	}
+}
+
+func tencentLister(client *cos.Client) listFunc {
+	return func(prefix string) ([]ObjectInfo, error) {
+		objects := make([]ObjectInfo, 0)
+		marker := ""
+		for {
+			result, _, err := client.Bucket.Get(context.Background(), &cos.BucketGetOptions{
+				Prefix:  cleanPrefix(prefix),
+				Marker:  marker,
+				MaxKeys: LIST_MAX_KEYS,
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, object := range result.Contents {
+				lastModified, err := time.Parse(time.RFC3339, object.LastModified)
+				if err != nil {
+					return nil, err
+				}
+				objects = append(objects, ObjectInfo{Key: object.Key, Size: object.Size, LastModified: lastModified})
+			}
+
+			if !result.IsTruncated || result.NextMarker == "" {
+				return objects, nil
+			}
+			marker = result.NextMarker
+		}
+	}
+}
+
+func qiniuLister(manager *qiniu.BucketManager, bucket string) listFunc {
+	return func(prefix string) ([]ObjectInfo, error) {
+		objects := make([]ObjectInfo, 0)
+		marker := ""
+		for {
+			entries, _, nextMarker, hasNext, err := manager.ListFiles(bucket, cleanPrefix(prefix), "", marker, LIST_MAX_KEYS)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				if entry.IsEmpty() {
+					continue
+				}
+				// 上传时间的单位为100纳秒
+				objects = append(objects, ObjectInfo{Key: entry.Key, Size: entry.Fsize, LastModified: time.Unix(0, entry.PutTime*100)})
+			}
+
+			if !hasNext || nextMarker == "" {
+				return objects, nil
+			}
+			marker = nextMarker
+		}
+	}
+}
+
+func aliyunSigner(bucket *oss.Bucket) signFunc {
+	return func(objectKey string, expires time.Duration) (string, error) {
+		return bucket.SignURL(cleanKey(objectKey), oss.HTTPGet, int64(expires.Seconds()))
+	}
+}
+
+func tencentSigner(client *cos.Client, config Config) signFunc {
+	return func(objectKey string, expires time.Duration) (string, error) {
+		signed, err := client.Object.GetPresignedURL(context.Background(), http.MethodGet, cleanKey(objectKey),
+			config.KeyID, config.KeySecret, expires, nil)
+		if err != nil {
+			return "", err
+		}
+		return signed.String(), nil
+	}
+}
+
+// 七牛云通过绑定的域名访问，公开空间不需要签名
+func qiniuSigner(mac *qbox.Mac, config Config) signFunc {
+	return func(objectKey string, expires time.Duration) (string, error) {
+		domain := "https://" + config.Domain
+		if !config.Private {
+			return qiniu.MakePublicURL(domain, cleanKey(objectKey)), nil
+		}
+		deadline := time.Now().Add(expires).Unix()
+		return qiniu.MakePrivateURL((*auth.Credentials)(mac), domain, cleanKey(objectKey), deadline), nil
+	}
+}
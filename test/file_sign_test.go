@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"clicli/service"
+	"github.com/spf13/viper"
+)
+
+// debug 命令  go test -v -run TestFileSign ./test
+
+// 解析签名链接，返回过期时间、签名和对象key
+func parseSignedUrl(t *testing.T, url string) (string, string, string) {
+	parts := strings.SplitN(strings.TrimPrefix(url, service.SIGNED_URL_PREFIX), "/", 3)
+	if len(parts) != 3 {
+		t.Fatalf("unexpected signed url %s", url)
+	}
+	return parts[0], parts[1], parts[2]
+}
+
+func TestFileSignScope(t *testing.T) {
+	viper.Set("security.url_secret", "test_secret")
+
+	// 视频目录中的分片可以使用同一个签名
+	expires, signature, _ := parseSignedUrl(t, service.SignFileUrl("video/abc/index.mpd"))
+	if _, ok := service.VerifyFileSignature(expires, signature, "video/abc/seg-1.m4s"); !ok {
+		t.Fatal("segment in the same directory should be allowed")
+	}
+	if _, ok := service.VerifyFileSignature(expires, signature, "video/def/index.mpd"); ok {
+		t.Fatal("other video directory should be rejected")
+	}
+
+	// 原始视频和转码中间文件不能访问
+	for _, key := range []string{"video/abc/upload.mp4", "video/abc/normalized.mp4", "video/abc/tmp_1080p_3000k.mp4"} {
+		if _, ok := service.VerifyFileSignature(expires, signature, key); ok {
+			t.Fatalf("%s should be rejected", key)
+		}
+	}
+
+	// 字幕只能访问签名的文件
+	expires, signature, key := parseSignedUrl(t, service.SignSubtitleUrl("/api/file/subtitle/a.vtt"))
+	if _, ok := service.VerifyFileSignature(expires, signature, key); !ok {
+		t.Fatal("signed subtitle should be allowed")
+	}
+	if _, ok := service.VerifyFileSignature(expires, signature, "subtitle/b.vtt"); ok {
+		t.Fatal("other subtitle should be rejected")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"testing"
+
+	"clicli/db/mysql"
+	"clicli/domain/model"
+	driver "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// 旧版本视频表中的记录，video和flv字段已经从model.Video中移除
+type legacyVideo struct {
+	gorm.Model
+	Title  string
+	Uid    uint
+	Status int
+	Video  *int
+	Flv    *string
+}
+
+func (table *legacyVideo) TableName() string {
+	return "video"
+}
+
+// 旧版直播迁移，需要一个空的MySQL数据库，测试结束后删除视频表
+// debug 命令  MYSQL_DSN="root:123456@tcp(127.0.0.1:3306)/clicli_test?charset=utf8mb4&parseTime=True&loc=Local" go test -v -run TestMigrateLegacyLive ./test
+func TestMigrateLegacyLive(t *testing.T) {
+	dsn := os.Getenv("MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("MYSQL_DSN not set")
+	}
+
+	db, err := gorm.Open(driver.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasTable(&model.Video{}) {
+		t.Skip("video table already exists, use an empty database")
+	}
+	if err := db.AutoMigrate(&model.Video{}, &legacyVideo{}); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Migrator().DropTable(&model.Video{})
+
+	live, regular := 0, 1
+	key := "http://127.0.0.1:8080/live/livestream/key.flv"
+	empty := ""
+	rows := []legacyVideo{
+		{Title: "普通视频", Video: &regular, Flv: &empty},
+		{Title: "提交过密钥的普通视频", Video: &regular, Flv: &key},
+		{Title: "旧版直播", Video: &live, Flv: &key},
+		{Title: "新上传的视频"},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	videoIds := []uint{rows[0].ID, rows[1].ID, rows[3].ID}
+
+	if !mysql.CheckLegacyLive(db) {
+		t.Fatal("legacy live rows not detected")
+	}
+	if ids := selectVideoIds(db.Scopes(mysql.ExcludeLegacyLive)); !equalIds(ids, videoIds) {
+		t.Fatalf("legacy live rows not excluded: %v", ids)
+	}
+
+	count, err := mysql.MigrateLegacyLive(db)
+	if err != nil || count != 1 {
+		t.Fatalf("unexpected migration result %d %v", count, err)
+	}
+	if ids := selectVideoIds(db); !equalIds(ids, videoIds) {
+		t.Fatalf("regular videos deleted: %v", ids)
+	}
+	if mysql.CheckLegacyLive(db) {
+		t.Fatal("legacy live rows left after migration")
+	}
+}
+
+func selectVideoIds(db *gorm.DB) (ids []uint) {
+	db.Model(&model.Video{}).Pluck("id", &ids)
+	return
+}
+
+func equalIds(a, b []uint) bool {
+	sort.Slice(a, func(i, j int) bool { return a[i] < a[j] })
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
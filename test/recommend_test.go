@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"clicli/recommend"
+)
+
+// debug 命令  go test -v -run TestRecommend ./test
+
+func TestRecommendSimilarity(t *testing.T) {
+	interactions := map[uint]map[uint]float64{
+		1: {1: 1, 2: 3},
+		2: {1: 1, 2: 1, 3: 1},
+		3: {1: 1, 2: 2},
+		4: {3: 1, 4: 1},
+	}
+
+	similar := recommend.Similarity(interactions, 2)
+	if len(similar[1]) != 2 || similar[1][0].ID != 2 {
+		t.Fatalf("unexpected similar items %+v", similar[1])
+	}
+	if len(similar[4]) != 1 || similar[4][0].ID != 3 {
+		t.Fatalf("unexpected similar items %+v", similar[4])
+	}
+}
+
+func TestRecommendExclude(t *testing.T) {
+	interactions := map[uint]map[uint]float64{
+		1: {1: 1, 2: 1, 3: 1},
+		2: {1: 1, 2: 1},
+		3: {1: 1, 4: 1},
+	}
+	similar := recommend.Similarity(interactions, 10)
+
+	// 已有行为和排除的视频不推荐
+	items := recommend.Recommend(map[uint]float64{1: 1}, similar, map[uint]bool{4: true}, 10)
+	if len(items) != 2 || items[0].ID != 2 || items[1].ID != 3 {
+		t.Fatalf("unexpected recommended items %+v", items)
+	}
+
+	if items := recommend.Recommend(map[uint]float64{1: 1}, similar, nil, 1); len(items) != 1 {
+		t.Fatalf("unexpected recommended items %+v", items)
+	}
+}
+
+func TestRecommendRelated(t *testing.T) {
+	weights := recommend.RelatedWeights{Partition: 1, Author: 1, Text: 2, CoWatch: 2, Popularity: 0.1}
+	items := recommend.Related([]recommend.RelatedCandidate{
+		{ID: 1, SamePartition: true, Clicks: 100000},
+		{ID: 2, SamePartition: true, Text: 1},
+		{ID: 3, CoWatch: 0.8, SameAuthor: true},
+		{ID: 4, Clicks: 10},
+	}, weights, 3)
+
+	if len(items) != 3 || items[0].ID != 2 || items[1].ID != 3 || items[2].ID != 1 {
+		t.Fatalf("unexpected related items %+v", items)
+	}
+}
+
+func TestRecommendLimitInteractions(t *testing.T) {
+	interactions := map[uint]map[uint]float64{
+		1: {1: 1, 2: 3, 3: 2, 4: 1},
+		2: {1: 1},
+	}
+
+	recommend.LimitInteractions(interactions, 2)
+	if len(interactions[1]) != 2 || interactions[1][2] != 3 || interactions[1][3] != 2 {
+		t.Fatalf("unexpected interactions %v", interactions[1])
+	}
+	if len(interactions[2]) != 1 {
+		t.Fatalf("unexpected interactions %v", interactions[2])
+	}
+}
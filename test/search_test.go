@@ -0,0 +1,225 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"clicli/search"
+)
+
+// debug 命令  go test -v -run TestSearch ./test
+
+func newSearchEngine(t *testing.T) search.Engine {
+	engine := search.NewMemory()
+	docs := []search.Document{
+		{ID: "1", Fields: map[string]string{"title": "原神新版本实况", "desc": "游戏实况"}, Attrs: map[string]float64{"uid": 1}},
+		{ID: "2", Fields: map[string]string{"title": "做饭教程", "desc": "今天来做原神里的料理"}, Attrs: map[string]float64{"uid": 2}},
+		{ID: "3", Fields: map[string]string{"title": "Golang Tutorial", "desc": "learn <go> from zero"}, Attrs: map[string]float64{"uid": 1}},
+	}
+	if err := engine.Rebuild(search.INDEX_VIDEO, docs); err != nil {
+		t.Fatal(err)
+	}
+	return engine
+}
+
+func searchIds(t *testing.T, engine search.Engine, query search.Query) []string {
+	query.Index = search.INDEX_VIDEO
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+	result, err := engine.Search(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, len(result.Hits))
+	for i, hit := range result.Hits {
+		ids[i] = hit.ID
+	}
+	if result.Total < len(ids) {
+		t.Fatalf("total %d less than hits %d", result.Total, len(ids))
+	}
+	return ids
+}
+
+func TestSearchChinese(t *testing.T) {
+	engine := newSearchEngine(t)
+	fields := map[string]float64{"title": 3, "desc": 1}
+
+	// 标题匹配的权重更高
+	ids := searchIds(t, engine, search.Query{Keywords: "原神", Fields: fields})
+	if len(ids) != 2 || ids[0] != "1" || ids[1] != "2" {
+		t.Fatalf("unexpected result %v", ids)
+	}
+
+	// 单个字
+	if ids := searchIds(t, engine, search.Query{Keywords: "饭"}); len(ids) != 1 || ids[0] != "2" {
+		t.Fatalf("unexpected result %v", ids)
+	}
+
+	// 大小写和全角字母
+	if ids := searchIds(t, engine, search.Query{Keywords: "ＧＯＬＡＮＧ"}); len(ids) != 1 || ids[0] != "3" {
+		t.Fatalf("unexpected result %v", ids)
+	}
+
+	if ids := searchIds(t, engine, search.Query{Keywords: "原神 golang"}); len(ids) != 0 {
+		t.Fatalf("unexpected result %v", ids)
+	}
+}
+
+func TestSearchFilterAndDelete(t *testing.T) {
+	engine := newSearchEngine(t)
+
+	ids := searchIds(t, engine, search.Query{Filters: map[string]float64{"uid": 1}})
+	if len(ids) != 2 {
+		t.Fatalf("unexpected result %v", ids)
+	}
+
+	engine.Delete(search.INDEX_VIDEO, "1")
+	if ids := searchIds(t, engine, search.Query{Keywords: "原神"}); len(ids) != 1 || ids[0] != "2" {
+		t.Fatalf("unexpected result %v", ids)
+	}
+
+	// 更新文档后旧内容不再匹配
+	engine.Index(search.INDEX_VIDEO, search.Document{ID: "2", Fields: map[string]string{"title": "做饭教程"}})
+	if ids := searchIds(t, engine, search.Query{Keywords: "原神"}); len(ids) != 0 {
+		t.Fatalf("unexpected result %v", ids)
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	engine := search.NewMemory()
+	for i := 0; i < 3; i++ {
+		engine.Index(search.INDEX_VIDEO, search.Document{
+			ID:         strconv.Itoa(i),
+			Fields:     map[string]string{"title": "相同的标题"},
+			Time:       time.Now().AddDate(0, 0, -i*100),
+			Popularity: float64(i * 1000),
+		})
+	}
+
+	if ids := searchIds(t, engine, search.Query{Keywords: "标题", RecencyWeight: 1}); ids[0] != "0" {
+		t.Fatalf("unexpected result %v", ids)
+	}
+	if ids := searchIds(t, engine, search.Query{Keywords: "标题", PopularityWeight: 1}); ids[0] != "2" {
+		t.Fatalf("unexpected result %v", ids)
+	}
+	if ids := searchIds(t, engine, search.Query{Keywords: "标题", Offset: 1, Limit: 1}); len(ids) != 1 {
+		t.Fatalf("unexpected result %v", ids)
+	}
+
+	// 返回数量不大于0时不返回结果
+	if ids := searchIds(t, engine, search.Query{Keywords: "标题", Limit: -1}); len(ids) != 0 {
+		t.Fatalf("unexpected result %v", ids)
+	}
+}
+
+func TestSearchHighlight(t *testing.T) {
+	engine := newSearchEngine(t)
+	result, _ := engine.Search(search.Query{Index: search.INDEX_VIDEO, Keywords: "go", Highlight: []string{"desc"}, Limit: 10})
+	if len(result.Hits) != 1 {
+		t.Fatalf("unexpected hits %d", len(result.Hits))
+	}
+
+	// 其他内容需要转义
+	if desc := result.Hits[0].Highlights["desc"]; desc != "learn &lt;<em>go</em>&gt; from zero" {
+		t.Fatalf("unexpected highlight %s", desc)
+	}
+
+	long := ""
+	for i := 0; i < 200; i++ {
+		long += "字"
+	}
+	if h := search.Highlight(long+"原神"+long, search.QueryTerms("原神")); len([]rune(h)) > search.FRAGMENT_SIZE+20 {
+		t.Fatalf("fragment too long %d", len([]rune(h)))
+	}
+}
+
+func TestSearchFacets(t *testing.T) {
+	engine := search.NewMemory()
+	durations := []float64{100, 700, 2000, 4000, 200}
+	for i, duration := range durations {
+		engine.Index(search.INDEX_VIDEO, search.Document{
+			ID:     strconv.Itoa(i),
+			Fields: map[string]string{"title": "视频" + strconv.Itoa(i)},
+			Attrs:  map[string]float64{"partition": float64(i % 2), "duration": duration, "clicks": float64(i)},
+		})
+	}
+
+	ranges := []search.Range{
+		{Attr: "duration", Max: 600},
+		{Attr: "duration", Min: 600, Max: 1800},
+		{Attr: "duration", Min: 1800},
+	}
+	result, err := engine.Search(search.Query{
+		Index:   search.INDEX_VIDEO,
+		Filters: map[string]float64{"partition": 0},
+		Ranges:  []search.Range{ranges[0]},
+		Sort:    "clicks",
+		Facets:  []search.Facet{{Attr: "partition"}, {Attr: "duration", Ranges: ranges}},
+		Limit:   10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 分区0且时长10分钟以下，按播放量排序
+	if result.Total != 2 || result.Hits[0].ID != "4" || result.Hits[1].ID != "0" {
+		t.Fatalf("unexpected result %+v", result.Hits)
+	}
+
+	// 时长统计不受时长条件影响
+	if durations := result.Facets["duration"]; durations[0].Count != 2 || durations[1].Count != 0 || durations[2].Count != 1 {
+		t.Fatalf("unexpected duration facets %+v", durations)
+	}
+
+	// 分区统计不受分区条件影响，分区1没有10分钟以下的视频
+	result, _ = engine.Search(search.Query{
+		Index:   search.INDEX_VIDEO,
+		Filters: map[string]float64{"partition": 1},
+		Ranges:  []search.Range{ranges[0]},
+		Facets:  []search.Facet{{Attr: "partition"}},
+	})
+	partitions := result.Facets["partition"]
+	if result.Total != 0 || len(partitions) != 1 || partitions[0].Value != 0 || partitions[0].Count != 2 {
+		t.Fatalf("unexpected partition facets %+v", partitions)
+	}
+}
+
+func TestSearchNormalize(t *testing.T) {
+	if s := search.Normalize("  ＧＯ　语言   教程 "); s != "go 语言 教程" {
+		t.Fatalf("unexpected result %q", s)
+	}
+}
+
+func TestSearchMinimumMatch(t *testing.T) {
+	engine := newSearchEngine(t)
+
+	// 默认需要匹配75%的查询词
+	if ids := searchIds(t, engine, search.Query{Keywords: "原神新版本攻略", Fields: map[string]float64{"title": 1}}); len(ids) != 0 {
+		t.Fatalf("unexpected result %v", ids)
+	}
+	query := search.Query{Keywords: "原神新版本攻略", Fields: map[string]float64{"title": 1}, MinimumMatch: 0.3}
+	if ids := searchIds(t, engine, query); len(ids) != 1 || ids[0] != "1" {
+		t.Fatalf("unexpected result %v", ids)
+	}
+}
+
+func TestSearchUpdateAttrs(t *testing.T) {
+	engine := search.NewMemory()
+	for i := 0; i < 2; i++ {
+		engine.Index(search.INDEX_VIDEO, search.Document{
+			ID:     strconv.Itoa(i),
+			Fields: map[string]string{"title": "视频"},
+			Attrs:  map[string]float64{"likes": float64(i), "uid": 1},
+		})
+	}
+
+	// 只更新属性，其他属性和检索字段不变
+	engine.UpdateAttrs(search.INDEX_VIDEO, "0", map[string]float64{"likes": 10}, 100)
+	engine.UpdateAttrs(search.INDEX_VIDEO, "2", map[string]float64{"likes": 10}, 100)
+	ids := searchIds(t, engine, search.Query{Keywords: "视频", Filters: map[string]float64{"uid": 1}, Sort: "likes"})
+	if len(ids) != 2 || ids[0] != "0" {
+		t.Fatalf("unexpected result %v", ids)
+	}
+}
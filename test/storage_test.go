@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"clicli/storage"
+)
+
+// 本地存储
+func TestLocalStorage(t *testing.T) {
+	s := storage.NewLocal(t.TempDir(), "/api/")
+	testStorage(t, s)
+
+	if url := s.GetObjectUrl("/image/../image/a.jpg"); url != "/api/image/a.jpg" {
+		t.Fatalf("unexpected url %s", url)
+	}
+}
+
+// S3兼容存储，需要本地MinIO
+// debug 命令  MINIO_ENDPOINT=http://127.0.0.1:9000 MINIO_ACCESS_KEY=minioadmin MINIO_SECRET_KEY=minioadmin MINIO_BUCKET=test go test -v -run TestMinioStorage ./test
+func TestMinioStorage(t *testing.T) {
+	endpoint := os.Getenv("MINIO_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("MINIO_ENDPOINT not set")
+	}
+
+	s, err := storage.NewS3(storage.Config{
+		KeyID:     os.Getenv("MINIO_ACCESS_KEY"),
+		KeySecret: os.Getenv("MINIO_SECRET_KEY"),
+		Bucket:    os.Getenv("MINIO_BUCKET"),
+		Endpoint:  endpoint,
+		Region:    os.Getenv("MINIO_REGION"),
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, s)
+
+	// 预签名链接可以直接下载
+	url, err := s.SignUrl("test/sign.txt", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PutObject("test/sign.txt", strings.NewReader("signed")); err != nil {
+		t.Fatal(err)
+	}
+	defer s.DeleteObject("test/sign.txt")
+
+	res, err := httpGet(url)
+	if err != nil || res != "signed" {
+		t.Fatalf("signed url failed: %v %q", err, res)
+	}
+}
+
+func testStorage(t *testing.T, s storage.Storage) {
+	prefix := "test/" + time.Now().Format("150405.000000") + "/"
+	content := []byte("clicli storage test")
+
+	if err := s.PutObject(prefix+"a b.txt", bytes.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+
+	file := t.TempDir() + "/file.txt"
+	os.WriteFile(file, content, 0644)
+	if err := s.PutObjectFromFile(prefix+"sub/file.txt", file); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := s.GetObject(prefix + "a b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if !bytes.Equal(data, content) {
+		t.Fatalf("unexpected content %q", data)
+	}
+
+	objects, err := s.ListObjects(prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("expected 2 objects, got %d", len(objects))
+	}
+
+	for _, o := range objects {
+		if err := s.DeleteObject(o.Key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if objects, _ := s.ListObjects(prefix); len(objects) != 0 {
+		t.Fatalf("expected no objects after delete, got %d", len(objects))
+	}
+}
+
+func httpGet(url string) (string, error) {
+	res, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	return string(data), err
+}
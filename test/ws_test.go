@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"clicli/domain/vo"
+	"clicli/util/convert"
+	"clicli/ws"
+	"github.com/gorilla/websocket"
+)
+
+// debug 命令  go test -race -v -run TestRoom ./test
+
+func newWsServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if uid := query.Get("uid"); uid != "" {
+			ws.MsgWsHandler(w, r, convert.StringToUint(uid))
+			return
+		}
+		ws.RoomWsHandler(w, r, query.Get("room"), query.Get("client_id"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func dialWs(t *testing.T, srv *httptest.Server, query string) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/?" + query
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// 读取消息直到收到指定类型
+func readUntil(t *testing.T, conn *websocket.Conn, messageType string) vo.WsMessageVO {
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var message vo.WsMessageVO
+		if err := conn.ReadJSON(&message); err != nil {
+			t.Fatalf("read %s message: %v", messageType, err)
+		}
+		if message.Type == messageType {
+			return message
+		}
+	}
+}
+
+func waitOnline(t *testing.T, room string, count int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for ws.OnlineCount(room) != count {
+		if time.Now().After(deadline) {
+			t.Fatalf("room %s online %d, want %d", room, ws.OnlineCount(room), count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func danmakuText(message vo.WsMessageVO) string {
+	data, _ := message.Data.(map[string]interface{})
+	text, _ := data["text"].(string)
+	return text
+}
+
+func TestRoomBroadcast(t *testing.T) {
+	srv := newWsServer(t)
+	a := dialWs(t, srv, "room=video:1&client_id=a")
+	b := dialWs(t, srv, "room=video:1&client_id=b")
+	other := dialWs(t, srv, "room=video:2&client_id=c")
+	waitOnline(t, "video:1", 2)
+	waitOnline(t, "video:2", 1)
+
+	ws.BroadcastDanmaku("video:1", vo.WsDanmakuVO{DanmakuVO: vo.DanmakuVO{Text: "hello"}})
+	for _, conn := range []*websocket.Conn{a, b} {
+		if text := danmakuText(readUntil(t, conn, ws.MESSAGE_DANMAKU)); text != "hello" {
+			t.Fatalf("unexpected danmaku %q", text)
+		}
+	}
+
+	// 其他房间只会收到在线人数
+	other.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	for {
+		var message vo.WsMessageVO
+		if err := other.ReadJSON(&message); err != nil {
+			break
+		}
+		if message.Type != ws.MESSAGE_ONLINE {
+			t.Fatalf("unexpected message %s in other room", message.Type)
+		}
+	}
+}
+
+func TestRoomOnlineNumber(t *testing.T) {
+	srv := newWsServer(t)
+	a := dialWs(t, srv, "room=video:3&client_id=a")
+	waitOnline(t, "video:3", 1)
+
+	b := dialWs(t, srv, "room=video:3&client_id=b")
+	waitOnline(t, "video:3", 2)
+	for {
+		data, _ := readUntil(t, a, ws.MESSAGE_ONLINE).Data.(map[string]interface{})
+		if data["number"] == float64(2) {
+			break
+		}
+	}
+
+	// 断开后房间人数减少
+	b.Close()
+	waitOnline(t, "video:3", 1)
+	for {
+		data, _ := readUntil(t, a, ws.MESSAGE_ONLINE).Data.(map[string]interface{})
+		if data["number"] == float64(1) {
+			break
+		}
+	}
+}
+
+func TestRoomDuplicateClient(t *testing.T) {
+	srv := newWsServer(t)
+	first := dialWs(t, srv, "room=video:4&client_id=same")
+	waitOnline(t, "video:4", 1)
+	dialWs(t, srv, "room=video:4&client_id=same")
+
+	// 相同ID的旧连接被关闭
+	first.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := first.ReadMessage(); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			break
+		}
+	}
+	waitOnline(t, "video:4", 1)
+}
+
+// 不读取消息的客户端不能阻塞广播
+func TestRoomSlowClient(t *testing.T) {
+	srv := newWsServer(t)
+	dialWs(t, srv, "room=video:5&client_id=slow")
+	fast := dialWs(t, srv, "room=video:5&client_id=fast")
+	waitOnline(t, "video:5", 2)
+
+	start := time.Now()
+	for i := 0; i < ws.SEND_BUFFER_SIZE*20; i++ {
+		ws.BroadcastDanmaku("video:5", vo.WsDanmakuVO{DanmakuVO: vo.DanmakuVO{Text: strconv.Itoa(i)}})
+	}
+	if time.Since(start) > time.Second {
+		t.Fatalf("broadcast blocked for %s", time.Since(start))
+	}
+
+	// 队列满时丢弃消息，之后的消息仍能送达
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for danmakuText(readUntil(t, fast, ws.MESSAGE_DANMAKU)) != "last" {
+		}
+	}()
+	time.Sleep(100 * time.Millisecond)
+	ws.BroadcastDanmaku("video:5", vo.WsDanmakuVO{DanmakuVO: vo.DanmakuVO{Text: "last"}})
+	<-done
+}
+
+// 并发连接、断开和广播，配合 -race 检查
+func TestRoomConcurrent(t *testing.T) {
+	srv := newWsServer(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/?room=video:6&client_id=" + strconv.Itoa(i)
+			conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+			conn.Close()
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			ws.BroadcastDanmaku("video:6", vo.WsDanmakuVO{DanmakuVO: vo.DanmakuVO{Text: strconv.Itoa(i)}})
+		}(i)
+	}
+	wg.Wait()
+	waitOnline(t, "video:6", 0)
+}
+
+func TestWhisper(t *testing.T) {
+	srv := newWsServer(t)
+	a := dialWs(t, srv, "uid=7")
+	b := dialWs(t, srv, "uid=7")
+	other := dialWs(t, srv, "uid=8")
+	time.Sleep(50 * time.Millisecond)
+
+	ws.SendMsg(7, map[string]string{"content": "hi"})
+	for _, conn := range []*websocket.Conn{a, b} {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var message map[string]string
+		if err := json.Unmarshal(data, &message); err != nil || message["content"] != "hi" {
+			t.Fatalf("unexpected message %s", data)
+		}
+	}
+
+	other.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if _, data, err := other.ReadMessage(); err == nil {
+		t.Fatalf("unexpected message %s", data)
+	}
+}
+
+// 发布失败的消息总线
+type failingBus struct{}
+
+func (failingBus) Publish(msg ws.BusMessage) error {
+	return errors.New("publish failed")
+}
+
+func (failingBus) Subscribe(handler func(msg ws.BusMessage)) {}
+
+func (failingBus) UpdateOnline(room string, count int) int {
+	return count
+}
+
+// 发布失败时仍然投递给当前节点的客户端
+func TestBusPublishFallback(t *testing.T) {
+	ws.SetBus(failingBus{})
+	defer ws.SetBus(ws.NewLocalBus())
+
+	srv := newWsServer(t)
+	conn := dialWs(t, srv, "uid=9")
+	time.Sleep(50 * time.Millisecond)
+
+	ws.SendMsg(9, map[string]string{"content": "fallback"})
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var message map[string]string
+	if err := json.Unmarshal(data, &message); err != nil || message["content"] != "fallback" {
+		t.Fatalf("unexpected message %s", data)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	srv := newWsServer(t)
+	conn := dialWs(t, srv, "room=video:9&client_id=a")
+	waitOnline(t, "video:9", 1)
+
+	ws.Shutdown()
+	waitOnline(t, "video:9", 0)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			break
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package cron
 
 import (
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +18,24 @@ func Init() {
 	// 每天凌晨2点同步播放量数据
 	c.Every(1).Days().At("2:00").Do(syncClicks)
 
+	// 每小时清理过期的分片上传会话
+	c.Every(1).Hour().Do(service.CleanExpiredUploadSession)
+
+	// 每天凌晨3点清理存储
+	c.Every(1).Days().At("3:00").Do(storageGC)
+
+	// 每天凌晨4点重建搜索索引，更新同步后的播放量
+	c.Every(1).Days().At("4:00").Do(service.RebuildSearchIndex)
+
+	// 每10分钟更新搜索索引中的点赞数和播放量
+	c.Every(10).Minutes().Do(service.RefreshSearchStats)
+
+	// 每6小时计算推荐视频
+	c.Every(6).Hours().Do(service.RebuildRecommend)
+
+	// 每分钟与媒体服务器同步直播在线状态
+	c.Every(1).Minute().Do(service.SyncLiveOnlineState)
+
 	<-c.Start()
 }
 
@@ -43,3 +62,19 @@ func syncClicks() {
 	}
 	zap.L().Info("播放量同步完成，耗时 " + time.Since(start).String())
 }
+
+// 清理已删除和未使用的文件
+func storageGC() {
+	start := time.Now()
+	dryRun, _ := service.GetStorageGcConfig()
+	zap.L().Info("开始清理存储，dry_run: " + strconv.FormatBool(dryRun))
+
+	report := service.RunStorageGC(dryRun)
+	for _, err := range report.Errors {
+		zap.L().Error("清理存储错误:" + err)
+	}
+
+	zap.L().Info("存储清理完成，视频文件夹" + strconv.Itoa(len(report.Videos)) + "个，图片" +
+		strconv.Itoa(len(report.Images)) + "个，文件" + strconv.Itoa(report.Objects) + "个，共" +
+		strconv.FormatInt(report.Size, 10) + "字节，耗时 " + time.Since(start).String())
+}
@@ -0,0 +1,124 @@
+package danmaku
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ASS画布尺寸
+const ASS_WIDTH, ASS_HEIGHT = 1920, 1080
+
+// 字号和行高
+const ASS_FONT_SIZE = 50
+const ASS_LINE_HEIGHT = ASS_FONT_SIZE + 4
+
+// 滚动弹幕和固定弹幕的显示时间(秒)
+const ASS_SCROLL_DURATION = 8
+const ASS_FIXED_DURATION = 4
+
+// 可用的行数，底部只占用下半屏
+const ASS_SCROLL_LINES = ASS_HEIGHT / ASS_LINE_HEIGHT
+const ASS_FIXED_LINES = ASS_HEIGHT / 2 / ASS_LINE_HEIGHT
+
+const assHeader = `[Script Info]
+ScriptType: v4.00+
+Collisions: Normal
+PlayResX: %d
+PlayResY: %d
+WrapStyle: 2
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Danmaku,Microsoft YaHei,%d,&H33FFFFFF,&H33FFFFFF,&H33000000,&H33000000,1,0,0,0,100,100,0,0,1,1,0,7,0,0,0,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+`
+
+// 导出为ASS字幕，滚动弹幕从右向左移动，顶部和底部弹幕居中显示
+func WriteASS(w io.Writer, items []Item) error {
+	sorted := make([]Item, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time < sorted[j].Time })
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, assHeader, ASS_WIDTH, ASS_HEIGHT, ASS_FONT_SIZE)
+
+	scroll := newLanes(ASS_SCROLL_LINES)
+	top := newLanes(ASS_FIXED_LINES)
+	bottom := newLanes(ASS_FIXED_LINES)
+	for _, item := range sorted {
+		text := escapeAss(item.Text)
+		width := float64(utf8.RuneCountInString(item.Text) * ASS_FONT_SIZE)
+		var effect string
+		var end float64
+
+		switch item.Type {
+		case TYPE_TOP:
+			end = item.Time + ASS_FIXED_DURATION
+			y := top.take(item.Time, end)*ASS_LINE_HEIGHT + ASS_LINE_HEIGHT
+			effect = fmt.Sprintf(`\an2\pos(%d,%d)`, ASS_WIDTH/2, y)
+		case TYPE_BOTTOM:
+			end = item.Time + ASS_FIXED_DURATION
+			y := ASS_HEIGHT - bottom.take(item.Time, end)*ASS_LINE_HEIGHT
+			effect = fmt.Sprintf(`\an2\pos(%d,%d)`, ASS_WIDTH/2, y)
+		default:
+			end = item.Time + ASS_SCROLL_DURATION
+			// 弹幕完全进入屏幕后该行即可放下一条
+			free := item.Time + ASS_SCROLL_DURATION*width/(ASS_WIDTH+width)
+			y := scroll.take(item.Time, free) * ASS_LINE_HEIGHT
+			effect = fmt.Sprintf(`\move(%d,%d,%d,%d)`, ASS_WIDTH, y, -int(width), y)
+		}
+
+		fmt.Fprintf(bw, "Dialogue: 0,%s,%s,Danmaku,,0,0,0,,{%s%s}%s\n",
+			assTimestamp(item.Time), assTimestamp(end), effect, assColor(item.Color), text)
+	}
+
+	return bw.Flush()
+}
+
+// 按行分配弹幕，所有行都被占用时选择最早空出的行
+type lanes []float64
+
+func newLanes(n int) lanes {
+	return make(lanes, n)
+}
+
+func (l lanes) take(start, free float64) int {
+	best := 0
+	for i, until := range l {
+		if until <= start {
+			best = i
+			break
+		}
+		if until < l[best] {
+			best = i
+		}
+	}
+	l[best] = free
+	return best
+}
+
+// ASS颜色格式为 &HBBGGRR
+func assColor(color string) string {
+	value, ok := ParseColor(color)
+	if !ok || value == 0xffffff {
+		return ""
+	}
+	r, g, b := value>>16&0xff, value>>8&0xff, value&0xff
+	return fmt.Sprintf(`\c&H%02X%02X%02X&`, b, g, r)
+}
+
+func assTimestamp(seconds float64) string {
+	cs := int(seconds*100 + 0.5)
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
+
+func escapeAss(text string) string {
+	text = strings.NewReplacer("\r", "", "\n", " ", "{", "｛", "}", "｝").Replace(text)
+	return strings.ReplaceAll(text, `\`, `＼`)
+}
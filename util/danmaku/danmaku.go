@@ -0,0 +1,49 @@
+package danmaku
+
+import (
+	"strconv"
+	"strings"
+)
+
+// 弹幕类型，与model.Danmaku的Type一致
+const (
+	TYPE_SCROLL = 0
+	TYPE_TOP    = 1
+	TYPE_BOTTOM = 2
+)
+
+// 默认颜色
+const DEFAULT_COLOR = "#ffffff"
+
+type Item struct {
+	Time  float64 // 秒
+	Type  int
+	Color string // #rrggbb
+	Text  string
+}
+
+// 解析颜色为RGB数值，支持#rgb和#rrggbb
+func ParseColor(color string) (int64, bool) {
+	hex := strings.TrimPrefix(strings.TrimSpace(color), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return 0, false
+	}
+
+	value, err := strconv.ParseInt(hex, 16, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// RGB数值转为#rrggbb
+func FormatColor(value int64) string {
+	if value < 0 || value > 0xffffff {
+		return DEFAULT_COLOR
+	}
+	hex := strconv.FormatInt(value, 16)
+	return "#" + strings.Repeat("0", 6-len(hex)) + hex
+}
@@ -0,0 +1,138 @@
+package danmaku
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// XML弹幕模式 1-3滚动 4底部 5顶部 6逆向 7高级 8代码
+const (
+	XML_MODE_SCROLL = 1
+	XML_MODE_BOTTOM = 4
+	XML_MODE_TOP    = 5
+	XML_MODE_BACK   = 6
+)
+
+// XML弹幕默认字号
+const XML_FONT_SIZE = 25
+
+var ErrXmlFormat = errors.New("invalid danmaku xml")
+
+type xmlDocument struct {
+	XMLName    xml.Name     `xml:"i"`
+	ChatServer string       `xml:"chatserver"`
+	ChatId     string       `xml:"chatid"`
+	MaxLimit   int          `xml:"maxlimit"`
+	Danmaku    []xmlDanmaku `xml:"d"`
+}
+
+type xmlDanmaku struct {
+	P    string `xml:"p,attr"`
+	Text string `xml:",chardata"`
+}
+
+// 导出为XML，p属性为 时间,模式,字号,颜色,发送时间戳,弹幕池,用户hash,弹幕ID
+func WriteXML(w io.Writer, chatId string, items []Item) error {
+	doc := xmlDocument{
+		ChatServer: "chat.bilibili.com",
+		ChatId:     chatId,
+		MaxLimit:   len(items),
+		Danmaku:    make([]xmlDanmaku, len(items)),
+	}
+
+	for i, item := range items {
+		color, ok := ParseColor(item.Color)
+		if !ok {
+			color = 0xffffff
+		}
+
+		p := []string{
+			strconv.FormatFloat(item.Time, 'f', 3, 64),
+			strconv.Itoa(typeToMode(item.Type)),
+			strconv.Itoa(XML_FONT_SIZE),
+			strconv.FormatInt(color, 10),
+			"0", "0", "0", strconv.Itoa(i + 1),
+		}
+		doc.Danmaku[i] = xmlDanmaku{P: strings.Join(p, ","), Text: item.Text}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// 解析XML弹幕，忽略高级弹幕和格式错误的条目
+func ReadXML(r io.Reader) ([]Item, error) {
+	var doc xmlDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, ErrXmlFormat
+	}
+
+	items := make([]Item, 0, len(doc.Danmaku))
+	for _, d := range doc.Danmaku {
+		p := strings.Split(d.P, ",")
+		if len(p) < 4 {
+			continue
+		}
+
+		t, err := strconv.ParseFloat(p[0], 64)
+		if err != nil || t < 0 {
+			continue
+		}
+
+		mode, err := strconv.Atoi(p[1])
+		if err != nil {
+			continue
+		}
+		itemType, ok := modeToType(mode)
+		if !ok {
+			continue
+		}
+
+		color := DEFAULT_COLOR
+		if value, err := strconv.ParseInt(p[3], 10, 64); err == nil {
+			color = FormatColor(value)
+		}
+
+		items = append(items, Item{
+			Time:  t,
+			Type:  itemType,
+			Color: color,
+			Text:  d.Text,
+		})
+	}
+
+	return items, nil
+}
+
+func typeToMode(t int) int {
+	switch t {
+	case TYPE_TOP:
+		return XML_MODE_TOP
+	case TYPE_BOTTOM:
+		return XML_MODE_BOTTOM
+	default:
+		return XML_MODE_SCROLL
+	}
+}
+
+// 逆向弹幕按滚动处理，高级弹幕不支持
+func modeToType(mode int) (int, bool) {
+	switch {
+	case mode >= 1 && mode <= 3, mode == XML_MODE_BACK:
+		return TYPE_SCROLL, true
+	case mode == XML_MODE_TOP:
+		return TYPE_TOP, true
+	case mode == XML_MODE_BOTTOM:
+		return TYPE_BOTTOM, true
+	default:
+		return 0, false
+	}
+}
@@ -1,6 +1,8 @@
 package random
 
 import (
+	crand "crypto/rand"
+	"encoding/hex"
 	"math/rand"
 	"strconv"
 	"time"
@@ -21,3 +23,16 @@ func GenerateNumberCode(length int) string {
 	}
 	return res
 }
+
+/**
+ * 使用加密随机数生成密钥
+ * param: length 随机字节数
+ * return: 十六进制字符串
+ */
+func GenerateSecretKey(length int) string {
+	b := make([]byte, length)
+	if _, err := crand.Read(b); err != nil {
+		panic("生成随机密钥失败")
+	}
+	return hex.EncodeToString(b)
+}
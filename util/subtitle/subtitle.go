@@ -0,0 +1,190 @@
+package subtitle
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 单个字幕文件的最大条数
+const MAX_CUES = 20000
+
+var ErrSubtitleFormat = errors.New("invalid subtitle format")
+
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// SRT中常见的ASS样式标签，如{\an8}
+var assTagReg = regexp.MustCompile(`\{\\[^}]*\}`)
+
+// 解析SRT或WebVTT字幕
+func Parse(data []byte) ([]Cue, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		return nil, ErrSubtitleFormat
+	}
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	isVTT := strings.HasPrefix(text, "WEBVTT")
+
+	cues := make([]Cue, 0)
+	for i, block := range splitBlocks(text) {
+		lines := strings.Split(block, "\n")
+
+		// WebVTT的文件头、注释、样式和区域定义
+		if isVTT && (i == 0 || isVTTMetadata(lines[0])) {
+			continue
+		}
+
+		// 第一行为序号(SRT)或标识(WebVTT)
+		timing := 0
+		if !strings.Contains(lines[0], "-->") {
+			timing = 1
+		}
+		if timing >= len(lines) {
+			return nil, ErrSubtitleFormat
+		}
+
+		start, end, err := parseTiming(lines[timing])
+		if err != nil {
+			return nil, err
+		}
+
+		content := strings.TrimSpace(assTagReg.ReplaceAllString(strings.Join(lines[timing+1:], "\n"), ""))
+		if content == "" {
+			continue
+		}
+
+		if len(cues) >= MAX_CUES {
+			return nil, ErrSubtitleFormat
+		}
+		cues = append(cues, Cue{Start: start, End: end, Text: content})
+	}
+
+	if len(cues) == 0 {
+		return nil, ErrSubtitleFormat
+	}
+
+	return cues, nil
+}
+
+// 写入WebVTT字幕
+func WriteVTT(w io.Writer, cues []Cue) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("WEBVTT\n")
+	for _, cue := range cues {
+		// 文本中不能出现空行和"-->"
+		text := strings.ReplaceAll(cue.Text, "-->", "->")
+		text = strings.Join(strings.FieldsFunc(text, func(r rune) bool { return r == '\n' }), "\n")
+		fmt.Fprintf(bw, "\n%s --> %s\n%s\n", FormatTime(cue.Start), FormatTime(cue.End), text)
+	}
+	return bw.Flush()
+}
+
+// 将SRT或WebVTT转为WebVTT
+func ToVTT(data []byte) ([]byte, error) {
+	cues, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := WriteVTT(&buf, cues); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// 格式化为WebVTT时间 hh:mm:ss.ttt
+func FormatTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// 按空行分割
+func splitBlocks(text string) []string {
+	blocks := make([]string, 0)
+	for _, block := range strings.Split(text, "\n\n") {
+		if block = strings.Trim(block, "\n"); strings.TrimSpace(block) != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+func isVTTMetadata(line string) bool {
+	for _, prefix := range []string{"NOTE", "STYLE", "REGION"} {
+		if line == prefix || strings.HasPrefix(line, prefix+" ") || strings.HasPrefix(line, prefix+"\t") {
+			return true
+		}
+	}
+	return false
+}
+
+// 解析时间轴行，忽略WebVTT的位置设置
+func parseTiming(line string) (time.Duration, time.Duration, error) {
+	parts := strings.SplitN(line, "-->", 2)
+	if len(parts) != 2 {
+		return 0, 0, ErrSubtitleFormat
+	}
+
+	start, err := parseTime(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	fields := strings.Fields(parts[1])
+	if len(fields) == 0 {
+		return 0, 0, ErrSubtitleFormat
+	}
+	end, err := parseTime(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if end < start {
+		return 0, 0, ErrSubtitleFormat
+	}
+	return start, end, nil
+}
+
+// 解析 hh:mm:ss,ttt 或 mm:ss.ttt
+func parseTime(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	sep := strings.LastIndexAny(value, ",.")
+	if sep < 0 {
+		return 0, ErrSubtitleFormat
+	}
+
+	ms, err := strconv.Atoi(value[sep+1:])
+	if err != nil || ms < 0 || len(value[sep+1:]) != 3 {
+		return 0, ErrSubtitleFormat
+	}
+
+	parts := strings.Split(value[:sep], ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, ErrSubtitleFormat
+	}
+
+	var seconds int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || (i > 0 && n >= 60) {
+			return 0, ErrSubtitleFormat
+		}
+		seconds = seconds*60 + n
+	}
+
+	return time.Duration(seconds)*time.Second + time.Duration(ms)*time.Millisecond, nil
+}
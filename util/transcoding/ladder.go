@@ -0,0 +1,160 @@
+package transcoding
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"clicli/util/number"
+)
+
+// 帧率策略
+const (
+	// 保持原帧率
+	FPS_SOURCE = "source"
+	// 不超过设定帧率
+	FPS_LIMIT = "limit"
+	// 固定为设定帧率
+	FPS_FIXED = "fixed"
+)
+
+// 编码配置(单个分辨率)
+type EncodingProfile struct {
+	Quality   int     `json:"quality" mapstructure:"quality"`       // 清晰度，对应视频短边像素，如1080
+	Bitrate   int     `json:"bitrate" mapstructure:"bitrate"`       // 视频码率(kbps)
+	Crf       int     `json:"crf" mapstructure:"crf"`               // 大于0时使用crf模式，码率作为上限
+	Codec     string  `json:"codec" mapstructure:"codec"`           // 编码器
+	Preset    string  `json:"preset" mapstructure:"preset"`         // 编码预设
+	FpsPolicy string  `json:"fps_policy" mapstructure:"fps_policy"` // 帧率策略
+	Fps       float64 `json:"fps" mapstructure:"fps"`               // 帧率
+}
+
+// 编码阶梯
+type EncodingConfig struct {
+	Profiles        []EncodingProfile `json:"profiles" mapstructure:"profiles"`
+	KeepAspectRatio bool              `json:"keep_aspect_ratio" mapstructure:"keep_aspect_ratio"` // 保持原视频宽高比
+	Portrait        bool              `json:"portrait" mapstructure:"portrait"`                   // 竖屏视频输出竖屏画面
+}
+
+// 默认编码阶梯
+func DefaultEncodingConfig() EncodingConfig {
+	return EncodingConfig{
+		Profiles: []EncodingProfile{
+			{Quality: 360, Bitrate: 500, Codec: "libx264", Preset: "medium", FpsPolicy: FPS_LIMIT, Fps: 30},
+			{Quality: 480, Bitrate: 900, Codec: "libx264", Preset: "medium", FpsPolicy: FPS_LIMIT, Fps: 30},
+			{Quality: 720, Bitrate: 2000, Codec: "libx264", Preset: "medium", FpsPolicy: FPS_LIMIT, Fps: 30},
+			{Quality: 1080, Bitrate: 3000, Codec: "libx264", Preset: "medium", FpsPolicy: FPS_LIMIT, Fps: 30},
+		},
+		KeepAspectRatio: true,
+		Portrait:        true,
+	}
+}
+
+// 按清晰度从低到高排序
+func (c *EncodingConfig) Sort() {
+	sort.Slice(c.Profiles, func(i, j int) bool {
+		return c.Profiles[i].Quality < c.Profiles[j].Quality
+	})
+}
+
+// 获取视频支持的最大清晰度，视频小于最低清晰度时返回最低清晰度
+func (c EncodingConfig) MaxQuality(width, height int) int {
+	quality := 0
+	for _, p := range c.Profiles {
+		if quality == 0 || c.fits(p.Quality, width, height) {
+			quality = p.Quality
+		}
+	}
+
+	return quality
+}
+
+// 判断视频是否满足清晰度要求
+func (c EncodingConfig) fits(quality, width, height int) bool {
+	if c.KeepAspectRatio && (c.Portrait || width >= height) {
+		// 按短边计算
+		return quality <= number.Min(width, height)
+	}
+
+	// 输出为16:9画面
+	if c.Portrait && height > width {
+		width, height = height, width
+	}
+	return quality <= height && landscapeWidth(quality) <= width
+}
+
+// 获取不超过最大清晰度的编码配置(从低到高)
+func (c EncodingConfig) SelectProfiles(quality int) []EncodingProfile {
+	profiles := make([]EncodingProfile, 0)
+	for _, p := range c.Profiles {
+		if p.Quality <= quality {
+			profiles = append(profiles, p)
+		}
+	}
+
+	// 视频小于最低清晰度时使用最低清晰度
+	if len(profiles) == 0 && len(c.Profiles) > 0 {
+		profiles = append(profiles, c.Profiles[0])
+	}
+
+	return profiles
+}
+
+// 生成缩放滤镜
+func (c EncodingConfig) scaleFilter(quality, width, height int) string {
+	portrait := c.Portrait && height > width
+	q := strconv.Itoa(quality)
+	w := strconv.Itoa(landscapeWidth(quality))
+
+	if c.KeepAspectRatio {
+		if portrait {
+			return "scale=" + q + ":-2"
+		}
+		if width >= height {
+			return "scale=-2:" + q
+		}
+		// 竖屏视频放入横屏画面，两侧填充黑边
+		return "scale=" + w + ":" + q + ":force_original_aspect_ratio=decrease,pad=" + w + ":" + q + ":(ow-iw)/2:(oh-ih)/2,setsar=1"
+	}
+
+	if portrait {
+		return "scale=" + q + ":" + w + ",setsar=1"
+	}
+	return "scale=" + w + ":" + q + ",setsar=1"
+}
+
+// 输出帧率，返回空字符串表示保持原帧率
+func (p EncodingProfile) outputFps(sourceFps float64) string {
+	switch p.FpsPolicy {
+	case FPS_FIXED:
+		return strconv.FormatFloat(p.Fps, 'f', -1, 64)
+	case FPS_LIMIT:
+		if sourceFps <= 0 || sourceFps > p.Fps {
+			return strconv.FormatFloat(p.Fps, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+// 16:9画面的宽度(偶数)
+func landscapeWidth(quality int) int {
+	return (quality*16/9 + 1) / 2 * 2
+}
+
+// 解析ffprobe帧率，如 30000/1001
+func parseFrameRate(rate string) float64 {
+	num, den, found := strings.Cut(rate, "/")
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0
+	}
+	if !found {
+		return n
+	}
+
+	d, err := strconv.ParseFloat(den, 64)
+	if err != nil || d == 0 {
+		return 0
+	}
+	return n / d
+}
@@ -0,0 +1,51 @@
+package transcoding
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+)
+
+// 拉取直播流录制为flv文件，直播结束或ctx取消时返回
+func RecordStream(ctx context.Context, streamUrl, outputFile string) error {
+	cmd := exec.Command("ffmpeg", "-hide_banner", "-y", "-rw_timeout", "15000000",
+		"-i", streamUrl, "-c", "copy", "-f", "flv", outputFile)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return errors.New(stderr.String())
+		}
+		return nil
+	case <-ctx.Done():
+		// 发送q让ffmpeg正常结束写入
+		io.WriteString(stdin, "q")
+		stdin.Close()
+		<-done
+		return nil
+	}
+}
+
+// 不重新编码，将录像封装为mp4
+func RemuxVideo(inputFile, outputFile string) error {
+	_, err := runCmd(exec.Command("ffmpeg", "-hide_banner", "-y", "-i", inputFile,
+		"-c", "copy", "-movflags", "+faststart", "-f", "mp4", outputFile), nil)
+	return err
+}
@@ -0,0 +1,81 @@
+package transcoding
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// 缩略图宽度
+const STORYBOARD_THUMB_WIDTH = 160
+
+// 每张雪碧图的列数和行数
+const STORYBOARD_COLUMNS, STORYBOARD_ROWS = 10, 10
+
+// 缩略图最小间隔(秒)
+const STORYBOARD_MIN_INTERVAL = 2
+
+// 单个视频最多生成的缩略图数量
+const STORYBOARD_MAX_THUMBS = 300
+
+// 生成进度条预览雪碧图和WebVTT缩略图轨道，返回vtt文件名
+func GenerateStoryboard(inputFile, outputDir string, duration float64, width, height int, onProgress ProgressFunc) (string, error) {
+	if duration <= 0 || width <= 0 || height <= 0 {
+		return "", fmt.Errorf("invalid video size or duration")
+	}
+
+	interval := math.Max(STORYBOARD_MIN_INTERVAL, math.Ceil(duration/STORYBOARD_MAX_THUMBS))
+	thumbWidth := STORYBOARD_THUMB_WIDTH
+	thumbHeight := (thumbWidth*height/width + 1) / 2 * 2
+
+	filter := fmt.Sprintf("fps=1/%s,scale=%d:%d,tile=%dx%d",
+		strconv.FormatFloat(interval, 'f', -1, 64), thumbWidth, thumbHeight, STORYBOARD_COLUMNS, STORYBOARD_ROWS)
+	command := []string{"-hide_banner", "-y", "-i", inputFile, "-map", "0:v:0", "-vf", filter, "-q:v", "5", outputDir + "storyboard_%03d.jpg"}
+	if _, err := runCmd(exec.Command("ffmpeg", command...), onProgress); err != nil {
+		return "", err
+	}
+
+	// 生成WebVTT，每个缩略图对应雪碧图中的一个区域
+	var vtt strings.Builder
+	vtt.WriteString("WEBVTT\n\n")
+	perSheet := STORYBOARD_COLUMNS * STORYBOARD_ROWS
+	count := int(math.Ceil(duration / interval))
+	for i := 0; i < count; i++ {
+		start := float64(i) * interval
+		end := math.Min(start+interval, duration)
+		pos := i % perSheet
+		x := pos % STORYBOARD_COLUMNS * thumbWidth
+		y := pos / STORYBOARD_COLUMNS * thumbHeight
+		// 使用相对路径，本地存储和OSS都可以访问
+		fmt.Fprintf(&vtt, "%s --> %s\nstoryboard_%03d.jpg#xywh=%d,%d,%d,%d\n\n",
+			vttTimestamp(start), vttTimestamp(end), i/perSheet+1, x, y, thumbWidth, thumbHeight)
+	}
+
+	vttFile := "storyboard.vtt"
+	return vttFile, os.WriteFile(outputDir+vttFile, []byte(vtt.String()), 0644)
+}
+
+// 在视频中均匀截取候选封面，返回封面文件名
+func GenerateCovers(inputFile, outputDir string, duration float64, count int) ([]string, error) {
+	covers := make([]string, 0, count)
+	for i := 1; i <= count; i++ {
+		seek := strconv.FormatFloat(duration*float64(i)/float64(count+1), 'f', 3, 64)
+		cover := "cover_" + strconv.Itoa(i) + ".jpg"
+		command := []string{"-hide_banner", "-y", "-ss", seek, "-i", inputFile, "-map", "0:v:0", "-frames:v", "1", "-q:v", "2", outputDir + cover}
+		if _, err := runCmd(exec.Command("ffmpeg", command...), nil); err != nil {
+			return covers, err
+		}
+		covers = append(covers, cover)
+	}
+
+	return covers, nil
+}
+
+// WebVTT时间格式 00:00:00.000
+func vttTimestamp(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -1,11 +1,14 @@
 package transcoding
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
 	"os/exec"
 	"strconv"
+	"strings"
 )
 
 type VideoInfoData struct {
@@ -14,21 +17,102 @@ type VideoInfoData struct {
 }
 
 type Streams struct {
-	CodecName string `json:"codec_name"`
-	Width     int    `json:"width,omitempty"`
-	Height    int    `json:"height,omitempty"`
-	PixFmt    string `json:"pix_fmt,omitempty"`
-	Duration  string `json:"duration"`
+	Index       int         `json:"index"`
+	CodecName   string      `json:"codec_name"`
+	CodecType   string      `json:"codec_type"`
+	Width       int         `json:"width,omitempty"`
+	Height      int         `json:"height,omitempty"`
+	PixFmt      string      `json:"pix_fmt,omitempty"`
+	RFrameRate  string      `json:"r_frame_rate,omitempty"`
+	Duration    string      `json:"duration"`
+	Disposition Disposition `json:"disposition"`
+	Tags        StreamTags  `json:"tags"`
+	SideData    []SideData  `json:"side_data_list"`
+}
+
+type StreamTags struct {
+	Rotate string `json:"rotate"`
+}
+
+type SideData struct {
+	Rotation int `json:"rotation"`
+}
+
+type Disposition struct {
+	AttachedPic int `json:"attached_pic"`
 }
 
 type Format struct {
-	BitRate string `json:"bit_rate"`
+	BitRate  string `json:"bit_rate"`
+	Duration string `json:"duration"`
 }
 
+// 获取第一个视频流(忽略封面图)
+func (v VideoInfoData) VideoStream() (Streams, bool) {
+	for _, stream := range v.Stream {
+		if stream.CodecType == "video" && stream.Disposition.AttachedPic == 0 {
+			return stream, true
+		}
+	}
+	return Streams{}, false
+}
+
+// 获取显示尺寸(处理手机视频的旋转信息)
+func (s Streams) DisplaySize() (int, int) {
+	rotation, _ := strconv.Atoi(s.Tags.Rotate)
+	for _, data := range s.SideData {
+		if data.Rotation != 0 {
+			rotation = data.Rotation
+		}
+	}
+
+	if rotation%180 != 0 {
+		return s.Height, s.Width
+	}
+	return s.Width, s.Height
+}
+
+// 获取帧率
+func (s Streams) FrameRate() float64 {
+	return parseFrameRate(s.RFrameRate)
+}
+
+// 获取第一个音频流
+func (v VideoInfoData) AudioStream() (Streams, bool) {
+	for _, stream := range v.Stream {
+		if stream.CodecType == "audio" {
+			return stream, true
+		}
+	}
+	return Streams{}, false
+}
+
+// 获取视频时长，视频流没有时长时使用容器时长
+func (v VideoInfoData) GetDuration() float64 {
+	if stream, ok := v.VideoStream(); ok {
+		if duration, err := strconv.ParseFloat(stream.Duration, 64); err == nil && duration > 0 {
+			return duration
+		}
+	}
+
+	duration, _ := strconv.ParseFloat(v.Format.Duration, 64)
+	return duration
+}
+
+// ffmpeg转码进度
+type Progress struct {
+	OutTime  float64 // 已处理的视频时长(秒)
+	Speed    float64 // 处理速度(倍速)
+	Finished bool    // 是否处理完成
+}
+
+// 转码进度回调
+type ProgressFunc func(progress Progress)
+
 // 获取视频信息
 func GetVideoInfo(input string) (videoData VideoInfoData, err error) {
 	cmd := exec.Command("ffprobe", "-i", input, "-v", "quiet", "-print_format", "json", "-show_format", "-show_streams")
-	out, err := runCmd(cmd)
+	out, err := runCmd(cmd, nil)
 	if err != nil {
 		return videoData, err
 	}
@@ -42,50 +126,93 @@ func GetVideoInfo(input string) (videoData VideoInfoData, err error) {
 	return videoData, nil
 }
 
+// 标准化视频：选取第一个视频流和第一个音频流，
+// 不支持的视频编码重新编码为h264，不支持的音频编码重新编码为aac
+func NormalizeVideo(inputFile, outputFile string, videoData VideoInfoData, onProgress ProgressFunc) error {
+	video, ok := videoData.VideoStream()
+	if !ok {
+		return errors.New("no video stream")
+	}
+
+	command := []string{"-hide_banner", "-y", "-i", inputFile, "-map", "0:" + strconv.Itoa(video.Index)}
+	if video.CodecName == "h264" && video.PixFmt == "yuv420p" {
+		command = append(command, "-c:v", "copy")
+	} else {
+		command = append(command, "-c:v", "libx264", "-preset", "veryfast", "-crf", "18", "-pix_fmt", "yuv420p")
+	}
+
+	if audio, ok := videoData.AudioStream(); ok {
+		command = append(command, "-map", "0:"+strconv.Itoa(audio.Index))
+		if audio.CodecName == "aac" {
+			command = append(command, "-c:a", "copy")
+		} else {
+			command = append(command, "-c:a", "aac", "-b:a", "192k")
+		}
+	}
+
+	command = append(command, "-sn", "-dn", "-movflags", "+faststart", "-f", "mp4", outputFile)
+
+	_, err := runCmd(exec.Command("ffmpeg", command...), onProgress)
+	return err
+}
+
 // 提取音频
-func ExtractingAudio(inputFile, outputDir string) (string, error) {
+func ExtractingAudio(inputFile, outputDir string, onProgress ProgressFunc) (string, error) {
 	output := outputDir + "audio.m4a"
-	cmd := exec.Command("ffmpeg", "-hide_banner", "-i", inputFile, "-vn", "-c", "copy", output)
-	_, err := runCmd(cmd)
+	cmd := exec.Command("ffmpeg", "-hide_banner", "-y", "-i", inputFile, "-vn", "-c", "copy", output)
+	_, err := runCmd(cmd, onProgress)
 	return output, err
 }
 
 // 压制视频
-func PressingVideo(inputFile, outputDir string, quality int) ([]string, error) {
-	outputFileList := make([]string, 0)
-	command := []string{"-hide_banner", "-i", inputFile, "-crf", "20"}
-	switch quality {
-	case 1080:
-		output1080 := outputDir + "tmp_1080p_3000k.mp4"
-		outputFileList = append(outputFileList, output1080)
-		command = append(command, "-c:v", "libx264", "-an", "-s", "1920x1080", "-r", "30000/1001", "-b:v", "3000k", output1080)
-		fallthrough
-	case 720:
-		output720 := outputDir + "tmp_720p_2000k.mp4"
-		outputFileList = append(outputFileList, output720)
-		command = append(command, "-c:v", "libx264", "-an", "-s", "1080x720", "-r", "30000/1001", "-b:v", "2000k", output720)
-		fallthrough
-	case 480:
-		output480 := outputDir + "tmp_480p_900k.mp4"
-		outputFileList = append(outputFileList, output480)
-		command = append(command, "-c:v", "libx264", "-an", "-s", "854x480", "-r", "30000/1001", "-b:v", "900k", output480)
-		fallthrough
-	case 360:
-		output360 := outputDir + "tmp_360p_500k.mp4"
-		outputFileList = append(outputFileList, output360)
-		command = append(command, "-c:v", "libx264", "-an", "-s", "640x360", "-r", "30000/1001", "-b:v", "500k", output360)
-	}
-
-	// 翻转 outputFileList ，从低分辨率到高分辨率
-	for i, j := 0, len(outputFileList)-1; i < j; i, j = i+1, j-1 {
-		outputFileList[i], outputFileList[j] = outputFileList[j], outputFileList[i]
-	}
-
-	_, err := runCmd(exec.Command("ffmpeg", command...))
+func PressingVideo(inputFile, outputDir string, config EncodingConfig, quality int, videoData VideoInfoData, onProgress ProgressFunc) ([]string, error) {
+	video, ok := videoData.VideoStream()
+	if !ok {
+		return nil, errors.New("no video stream")
+	}
+
+	width, height := video.DisplaySize()
+	profiles := config.SelectProfiles(quality)
+	outputFileList := PressingOutputs(outputDir, profiles)
+	command := []string{"-hide_banner", "-y", "-i", inputFile}
+	for i, p := range profiles {
+		bitrate := strconv.Itoa(p.Bitrate) + "k"
+		command = append(command, "-map", "0:v:0", "-an", "-c:v", p.Codec, "-preset", p.Preset)
+		if p.Crf > 0 {
+			// crf模式，码率作为上限
+			command = append(command, "-crf", strconv.Itoa(p.Crf), "-maxrate", bitrate)
+		} else {
+			command = append(command, "-b:v", bitrate, "-maxrate", strconv.Itoa(p.Bitrate*3/2)+"k")
+		}
+		command = append(command, "-bufsize", strconv.Itoa(p.Bitrate*2)+"k", "-pix_fmt", "yuv420p")
+		command = append(command, "-vf", config.scaleFilter(p.Quality, width, height))
+		if fps := p.outputFps(video.FrameRate()); fps != "" {
+			command = append(command, "-r", fps)
+		}
+		// 固定关键帧间隔，保证不同分辨率的分片对齐
+		command = append(command, "-force_key_frames", "expr:gte(t,n_forced*2)", "-sc_threshold", "0")
+		if p.Codec == "libx265" {
+			command = append(command, "-tag:v", "hvc1")
+		}
+		command = append(command, outputFileList[i])
+	}
+
+	_, err := runCmd(exec.Command("ffmpeg", command...), onProgress)
 	return outputFileList, err
 }
 
-func GenerateDash(videoFiles []string, audioFile, outputDir, outputName string) error {
+// 获取压制后的文件列表(与编码配置顺序一致)
+func PressingOutputs(outputDir string, profiles []EncodingProfile) []string {
+	outputFileList := make([]string, len(profiles))
+	for i, p := range profiles {
+		outputFileList[i] = outputDir + "tmp_" + strconv.Itoa(p.Quality) + "p_" + strconv.Itoa(p.Bitrate) + "k.mp4"
+	}
+
+	return outputFileList
+}
+
+// 生成dash分片，同时生成使用相同fMP4分片的HLS播放列表(master.m3u8)
+func GenerateDash(videoFiles []string, audioFile, outputDir, outputName string, onProgress ProgressFunc) error {
 	mapCommand := make([]string, 0)
 	command := make([]string, 0)
 
@@ -99,28 +226,77 @@ func GenerateDash(videoFiles []string, audioFile, outputDir, outputName string)
 		mapCommand = append(mapCommand, "-map", strconv.Itoa(i))
 	}
 
-	// 添加音频
-	command = append(command, "-i", audioFile)
-	mapCommand = append(mapCommand, "-map", strconv.Itoa(len(videoFiles)))
+	// 添加音频(没有音频轨道时audioFile为空)
+	if audioFile != "" {
+		command = append(command, "-i", audioFile)
+		mapCommand = append(mapCommand, "-map", strconv.Itoa(len(videoFiles)))
+	}
 
 	// 合并命令
-	command = append(command, "-c", "copy")
+	command = append(command, "-y", "-c", "copy")
 	command = append(command, mapCommand...)
-	command = append(command, "-f", "dash", "-init_seg_name", initStreamName, "-media_seg_name", chunkStreamName, mpdName)
+	command = append(command, "-f", "dash", "-hls_playlist", "1", "-init_seg_name", initStreamName, "-media_seg_name", chunkStreamName, mpdName)
 
-	_, err := runCmd(exec.Command("ffmpeg", command...))
+	_, err := runCmd(exec.Command("ffmpeg", command...), onProgress)
 	return err
 }
 
-func runCmd(cmd *exec.Cmd) (bytes.Buffer, error) {
+// 执行命令，onProgress不为空时通过ffmpeg的-progress参数获取进度
+func runCmd(cmd *exec.Cmd, onProgress ProgressFunc) (bytes.Buffer, error) {
 	var out bytes.Buffer
 	var stderr bytes.Buffer
-	cmd.Stdout = &out
 	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
+	if onProgress == nil {
+		cmd.Stdout = &out
+		if err := cmd.Run(); err != nil {
+			return out, errors.New(stderr.String())
+		}
+		return out, nil
+	}
+
+	// 进度信息输出到stdout
+	cmd.Args = append([]string{cmd.Args[0], "-progress", "pipe:1", "-nostats"}, cmd.Args[1:]...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return out, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return out, err
+	}
+
+	parseProgress(stdout, onProgress)
+
+	if err := cmd.Wait(); err != nil {
 		return out, errors.New(stderr.String())
 	}
 
 	return out, nil
 }
+
+// 解析ffmpeg -progress 输出，每个进度块以progress=continue或progress=end结尾
+func parseProgress(r io.Reader, onProgress ProgressFunc) {
+	var progress Progress
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !found {
+			continue
+		}
+
+		switch key {
+		case "out_time_us":
+			if us, err := strconv.ParseInt(value, 10, 64); err == nil && us > 0 {
+				progress.OutTime = float64(us) / 1e6
+			}
+		case "speed":
+			if speed, err := strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64); err == nil {
+				progress.Speed = speed
+			}
+		case "progress":
+			progress.Finished = value == "end"
+			onProgress(progress)
+		}
+	}
+}
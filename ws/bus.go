@@ -0,0 +1,162 @@
+package ws
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"clicli/cache"
+	"clicli/util/random"
+	"go.uber.org/zap"
+)
+
+// 跨节点消息类型
+const (
+	// 房间广播
+	BUS_ROOM = "room"
+	// 发送给指定用户
+	BUS_WHISPER = "whisper"
+)
+
+// 节点心跳间隔
+const NODE_HEARTBEAT_INTERVAL = 10 * time.Second
+
+// 节点间传递的消息
+type BusMessage struct {
+	Node    string          `json:"node"`
+	Kind    string          `json:"kind"`
+	Room    string          `json:"room,omitempty"`
+	User    uint            `json:"user,omitempty"`
+	Content json.RawMessage `json:"content"`
+}
+
+// 消息总线，所有节点(包括发送消息的节点)都会收到发布的消息
+type Bus interface {
+	// 发布消息
+	Publish(msg BusMessage) error
+	// 设置消息处理函数
+	Subscribe(handler func(msg BusMessage))
+	// 更新当前节点的房间在线人数，返回所有节点的总人数
+	UpdateOnline(room string, count int) int
+}
+
+// 当前节点ID
+var nodeId = random.GenerateSecretKey(8)
+
+var (
+	bus    Bus
+	busMux sync.RWMutex
+)
+
+func init() {
+	SetBus(NewLocalBus())
+}
+
+// 设置消息总线，多节点部署时使用RedisBus
+func SetBus(b Bus) {
+	busMux.Lock()
+	bus = b
+	busMux.Unlock()
+	b.Subscribe(deliver)
+}
+
+func getBus() Bus {
+	busMux.RLock()
+	defer busMux.RUnlock()
+	return bus
+}
+
+// 投递收到的消息到当前节点的客户端
+func deliver(msg BusMessage) {
+	switch msg.Kind {
+	case BUS_ROOM:
+		roomHub.Broadcast(msg.Room, msg.Content)
+	case BUS_WHISPER:
+		whisperHub.Broadcast(userGroup(msg.User), msg.Content)
+	}
+}
+
+func publish(msg BusMessage, content interface{}) {
+	data, err := json.Marshal(content)
+	if err != nil {
+		zap.L().Error("序列化ws消息失败" + err.Error())
+		return
+	}
+
+	msg.Node = nodeId
+	msg.Content = data
+	// 发布失败时只投递给当前节点的客户端
+	if err := getBus().Publish(msg); err != nil {
+		zap.L().Error("发布ws消息失败" + err.Error())
+		deliver(msg)
+	}
+}
+
+// 单节点消息总线
+type LocalBus struct {
+	mux     sync.RWMutex
+	handler func(msg BusMessage)
+}
+
+func NewLocalBus() *LocalBus {
+	return &LocalBus{}
+}
+
+func (b *LocalBus) Publish(msg BusMessage) error {
+	b.mux.RLock()
+	handler := b.handler
+	b.mux.RUnlock()
+
+	if handler != nil {
+		handler(msg)
+	}
+	return nil
+}
+
+func (b *LocalBus) Subscribe(handler func(msg BusMessage)) {
+	b.mux.Lock()
+	b.handler = handler
+	b.mux.Unlock()
+}
+
+func (b *LocalBus) UpdateOnline(room string, count int) int {
+	return count
+}
+
+// 基于Redis发布订阅的消息总线
+type RedisBus struct{}
+
+func NewRedisBus() *RedisBus {
+	// 定时刷新节点存活状态，节点异常退出后其在线人数不再计入
+	cache.RefreshWsNode(nodeId)
+	go func() {
+		for range time.Tick(NODE_HEARTBEAT_INTERVAL) {
+			cache.RefreshWsNode(nodeId)
+		}
+	}()
+	return &RedisBus{}
+}
+
+func (b *RedisBus) Publish(msg BusMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return cache.Publish(cache.WS_CHANNEL, data)
+}
+
+func (b *RedisBus) Subscribe(handler func(msg BusMessage)) {
+	cache.Subscribe(cache.WS_CHANNEL, func(payload string) {
+		var msg BusMessage
+		if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+			zap.L().Error("解析ws消息失败" + err.Error())
+			return
+		}
+		handler(msg)
+	})
+}
+
+func (b *RedisBus) UpdateOnline(room string, count int) int {
+	cache.SetWsRoomOnline(room, nodeId, count)
+	return cache.GetWsRoomOnline(room)
+}
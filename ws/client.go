@@ -0,0 +1,121 @@
+package ws
+
+import (
+	"net/http"
+	"time"
+
+	"clicli/util/random"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	// 写入超时时间
+	WRITE_WAIT = 10 * time.Second
+	// 等待客户端pong的超时时间
+	PONG_WAIT = 60 * time.Second
+	// 服务端ping间隔，需要小于PONG_WAIT
+	PING_PERIOD = PONG_WAIT * 9 / 10
+	// 客户端消息最大长度
+	MAX_MESSAGE_SIZE = 512
+	// 发送队列长度
+	SEND_BUFFER_SIZE = 64
+)
+
+var wsupgrader = websocket.Upgrader{
+	ReadBufferSize:   1024,
+	WriteBufferSize:  1024,
+	HandshakeTimeout: 5 * time.Second,
+	// 取消ws跨域校验
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+type Client struct {
+	hub   *Hub
+	conn  *websocket.Conn
+	group string
+	id    string
+	// 待发送的消息，由hub关闭
+	send chan []byte
+}
+
+// 升级为websocket并加入hub，连接断开后返回；id为空时每个连接独立
+func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request, group, id string) {
+	conn, err := wsupgrader.Upgrade(w, r, nil)
+	if err != nil {
+		zap.L().Error("升级websocket失败，原因 " + err.Error())
+		return
+	}
+
+	if id == "" {
+		id = random.GenerateSecretKey(8)
+	}
+
+	c := &Client{
+		hub:   hub,
+		conn:  conn,
+		group: group,
+		id:    id,
+		send:  make(chan []byte, SEND_BUFFER_SIZE),
+	}
+	hub.register(c)
+
+	go c.writePump()
+	c.readPump()
+}
+
+// 读取客户端消息，处理pong和关闭帧，连接断开时移除客户端
+func (c *Client) readPump() {
+	defer func() {
+		c.hub.unregister(c)
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(MAX_MESSAGE_SIZE)
+	c.conn.SetReadDeadline(time.Now().Add(PONG_WAIT))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(PONG_WAIT))
+	})
+
+	for {
+		// 客户端不需要发送业务消息，读取只用于检测连接状态
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				zap.L().Info("websocket连接断开" + err.Error())
+			}
+			return
+		}
+	}
+}
+
+// 发送消息和心跳，所有写入都在这里进行
+func (c *Client) writePump() {
+	pingTicker := time.NewTicker(PING_PERIOD)
+	defer func() {
+		pingTicker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(WRITE_WAIT))
+			if !ok {
+				// 发送队列被关闭
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-pingTicker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(WRITE_WAIT))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package ws
+
+import "sync"
+
+// 按分组管理客户端，分组为房间ID或用户ID
+type Hub struct {
+	mux    sync.RWMutex
+	groups map[string]map[string]*Client // 分组 -> 客户端ID -> 客户端
+	// 分组内客户端变化时调用
+	onChange func(group string)
+}
+
+func NewHub(onChange func(group string)) *Hub {
+	return &Hub{
+		groups:   make(map[string]map[string]*Client),
+		onChange: onChange,
+	}
+}
+
+// 添加客户端，相同ID的旧连接会被关闭
+func (h *Hub) register(c *Client) {
+	h.mux.Lock()
+	clients := h.groups[c.group]
+	if clients == nil {
+		clients = make(map[string]*Client)
+		h.groups[c.group] = clients
+	}
+	if old, ok := clients[c.id]; ok {
+		close(old.send)
+	}
+	clients[c.id] = c
+	h.mux.Unlock()
+
+	if h.onChange != nil {
+		h.onChange(c.group)
+	}
+}
+
+// 移除客户端并关闭发送队列
+func (h *Hub) unregister(c *Client) {
+	h.mux.Lock()
+	removed := false
+	if clients := h.groups[c.group]; clients[c.id] == c {
+		delete(clients, c.id)
+		close(c.send)
+		removed = true
+		if len(clients) == 0 {
+			delete(h.groups, c.group)
+		}
+	}
+	h.mux.Unlock()
+
+	if removed && h.onChange != nil {
+		h.onChange(c.group)
+	}
+}
+
+// 发送消息到分组内的所有客户端，发送队列已满的客户端丢弃该消息
+func (h *Hub) Broadcast(group string, message []byte) {
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+	for _, c := range h.groups[group] {
+		select {
+		case c.send <- message:
+		default:
+		}
+	}
+}
+
+// 分组内的客户端数量
+func (h *Hub) Count(group string) int {
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+	return len(h.groups[group])
+}
+
+// 关闭所有客户端，返回关闭前存在的分组
+func (h *Hub) Close() []string {
+	h.mux.Lock()
+	groups := make([]string, 0, len(h.groups))
+	for group, clients := range h.groups {
+		for _, c := range clients {
+			close(c.send)
+		}
+		groups = append(groups, group)
+	}
+	h.groups = make(map[string]map[string]*Client)
+	h.mux.Unlock()
+	return groups
+}
@@ -0,0 +1,9 @@
+package ws
+
+// 关闭当前节点的所有连接，并更新房间的集群在线人数
+func Shutdown() {
+	whisperHub.Close()
+	for _, room := range roomHub.Close() {
+		BroadcastNumber(room)
+	}
+}